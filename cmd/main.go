@@ -1,78 +1,244 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"gpu-cloudsim/models"
 	"gpu-cloudsim/pkg/broker"
+	"gpu-cloudsim/pkg/engine"
+	"gpu-cloudsim/pkg/manifest"
+	"gpu-cloudsim/pkg/network"
 	"gpu-cloudsim/pkg/orchestrator"
 	"gpu-cloudsim/pkg/qos"
+	"gpu-cloudsim/pkg/report"
+	"gpu-cloudsim/pkg/scenario"
 	"gpu-cloudsim/pkg/scheduler"
+	"hash/fnv"
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
-const (
-	simulationDuration     = 3 * time.Minute
-	workloadChangeInterval = 30 * time.Second
-	numHosts               = 100 // Number of hosts
-	numGPUs                = 300 // Number of GPUs
-	numContainers          = 100 // Number of containers
-)
-
 func main() {
+	scenarioPath := flag.String("scenario", "", "path to a JSON scenario file (defaults to the built-in scenario)")
+	seed := flag.Int64("seed", 0, "seed for the random number generator (overrides the scenario's seed; without either one is picked from the clock)")
+	replay := flag.String("replay", "", "path to a run manifest to replay exactly")
+	outDir := flag.String("out", ".", "directory for log files and the run manifest")
+	flag.Parse()
+	// Only a seed given on the command line overrides the scenario's, so
+	// that -seed 0 is honoured rather than taken for the default.
+	var seedOverride *int64
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedOverride = seed
+		}
+	})
+
 	fmt.Println("Starting GPU Cloudsim...")
 
-	hosts := createHosts()
-	gpus := createGPUs()
-	containers := createContainers(gpus)
-	qosMonitor := createQoSMonitor()
+	var m *manifest.Manifest
+	if *replay != "" {
+		loaded, err := manifest.Load(*replay)
+		if err != nil {
+			log.Fatalf("Error loading manifest: %v", err)
+		}
+		m = loaded
+		fmt.Printf("Replaying run with seed %d from %s\n", m.Seed, *replay)
+	} else {
+		sc := scenario.Default()
+		if *scenarioPath != "" {
+			loaded, err := scenario.Load(*scenarioPath)
+			if err != nil {
+				log.Fatalf("Error loading scenario: %v", err)
+			}
+			sc = loaded
+		}
+		m = newManifest(sc, *scenarioPath, seedOverride)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatalf("Error creating output directory %s: %v", *outDir, err)
+	}
+	manifestPath := filepath.Join(*outDir, manifest.FileName)
+	if err := manifest.Write(manifestPath, m); err != nil {
+		log.Fatalf("Error writing manifest %s: %v", manifestPath, err)
+	}
+	fmt.Printf("Scenario %q, seed %d (manifest written to %s)\n", m.Scenario.Name, m.Seed, manifestPath)
+
+	sc := &m.Scenario
+	rng := rand.New(rand.NewSource(m.Seed))
+	snapshot := sc.Generate(rng)
+	qosMonitor := createQoSMonitor(sc.QoS)
+
+	// Run every strategy concurrently, each on its own copy of the cluster
+	// so that no strategy sees placements or workload changes from another.
+	// With several rebalance policies, every strategy runs with each of them.
+	policies := sc.RebalancePolicies
+	if len(policies) == 0 {
+		policies = []string{""}
+	}
+	results := make([]report.StrategyResult, len(sc.Strategies)*len(policies))
+	var wg sync.WaitGroup
+	for i, name := range sc.Strategies {
+		for j, policyName := range policies {
+			strategy, err := scheduler.New(name)
+			if err != nil {
+				log.Fatalf("Error creating strategy: %v", err)
+			}
+			var policy orchestrator.RebalancePolicy = &orchestrator.ThresholdPolicy{}
+			runName := name
+			if policyName != "" {
+				if policy, err = orchestrator.NewRebalancePolicy(policyName); err != nil {
+					log.Fatalf("Error creating rebalance policy: %v", err)
+				}
+				runName = name + "-" + policyName
+			}
+			cluster := snapshot.Clone()
+
+			wg.Add(1)
+			go func(i int, name, runName string) {
+				defer wg.Done()
+				fmt.Printf("Running simulation with %s strategy...\n", runName)
+				// Runs of one strategy share its seed, so rebalance policies
+				// face the same workload changes.
+				results[i] = runSimulation(runName, strategy, policy, cluster, qosMonitor, sc, strategySeed(m.Seed, name), *outDir)
+				results[i].Rebalance = policyName
+				fmt.Printf("%s simulation finished.\n", runName)
+			}(i*len(policies)+j, name, runName)
+		}
+	}
+	wg.Wait()
+
+	comparison := &report.Comparison{
+		Scenario: sc.Name,
+		Seed:     m.Seed,
+		Duration: time.Duration(sc.Duration).String(),
+		Results:  results,
+	}
+	reportBase := filepath.Join(*outDir, "comparison_report")
+	if err := report.WriteFiles(reportBase, comparison); err != nil {
+		log.Fatalf("Error writing comparison report: %v", err)
+	}
+	if err := report.WriteMarkdown(os.Stdout, comparison); err != nil {
+		log.Fatalf("Error printing comparison report: %v", err)
+	}
+
+	fmt.Printf("All simulations complete. Check the log files and %s.{md,json} for details.\n", reportBase)
+}
 
-	// Define scheduling strategies
-	strategies := map[string]scheduler.Scheduler{
-		"Priority":   &scheduler.PrioritySchedulingStrategy{},
-		"BinPacking": &scheduler.BinPackingStrategy{},
-		"RoundRobin": &scheduler.RoundRobinStrategy{},
+// newManifest resolves the seed for a fresh run: the command-line flag wins,
+// then the scenario's own seed, and otherwise one is picked from the clock.
+// Either may be nil when unset.
+func newManifest(sc *scenario.Scenario, scenarioPath string, seed *int64) *manifest.Manifest {
+	if seed == nil {
+		seed = sc.Seed
+	}
+	resolved := time.Now().UnixNano()
+	if seed != nil {
+		resolved = *seed
 	}
 
-	// Run simulation for each strategy
-	for name, strategy := range strategies {
-		fmt.Printf("Running simulation with %s strategy...\n", name)
-		runSimulation(name, strategy, hosts, containers, qosMonitor, gpus)
+	return &manifest.Manifest{
+		Seed:         resolved,
+		CreatedAt:    time.Now().UTC(),
+		ScenarioFile: scenarioPath,
+		Scenario:     *sc,
 	}
+}
 
-	fmt.Println("All simulations complete. Check the log files for details.")
+// strategySeed derives a per-strategy seed so that each strategy's workload
+// changes are reproducible regardless of the order strategies are run in.
+func strategySeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
 }
 
-func runSimulation(name string, strategy scheduler.Scheduler, hosts []*models.Host, containers []*models.Container, qosMonitor *qos.QoS, gpus []*models.GPU) {
-	logFileName := fmt.Sprintf("%s_simulation.log", name)
-	logger := setupLogger(logFileName)
+func runSimulation(name string, strategy scheduler.Scheduler, policy orchestrator.RebalancePolicy, cluster *models.Cluster, qosMonitor *qos.QoS, sc *scenario.Scenario, seed int64, outDir string) report.StrategyResult {
+	eng := engine.NewEngine()
+	logFileName := filepath.Join(outDir, fmt.Sprintf("%s_simulation.log", name))
+	logger := setupLogger(logFileName, eng)
 
 	b := broker.NewBroker(strategy)
 
-	// Distribute GPUs among hosts
-	for i, host := range hosts {
-		host.AddGPU(gpus[i*2%len(gpus)])
-		host.AddGPU(gpus[(i*2+1)%len(gpus)])
+	for _, host := range cluster.Hosts {
 		b.AddHost(host)
 	}
+	if sc.PowerCap != nil {
+		b.SetPowerCap(sc.PowerCap.Config())
+	}
 
-	orch := orchestrator.NewOrchestrator(b, qosMonitor, logger)
-
-	logger.Printf("Starting %s simulation\n", name)
-
-	err := orch.Run(containers, simulationDuration)
-	if err != nil {
-		logger.Printf("Error running orchestrator: %v", err)
-		return
+	orch := orchestrator.NewOrchestrator(b, qosMonitor, logger, eng)
+	orch.Rebalance = policy
+	if sc.Network != nil {
+		orch.UseNetwork(network.New(eng, cluster.Hosts, sc.Network.Config()))
+	}
+	if sc.Migration != nil {
+		orch.Migration = sc.Migration.Config()
+	}
+	if sc.MigrationLimits != nil {
+		orch.Limits = sc.MigrationLimits.Config()
+	}
+	if sc.Consolidation != nil {
+		orch.Consolidation = sc.Consolidation.Config()
 	}
 
+	logger.Printf("Starting %s simulation (seed %d)\n", name, seed)
+
 	// Simulate workload changes
-	go simulateWorkloadChanges(orch, simulationDuration, logger)
+	simulateWorkloadChanges(orch, rand.New(rand.NewSource(seed)), time.Duration(sc.WorkloadChangeInterval), logger)
+
+	orch.Run(cluster, time.Duration(sc.Duration))
 
-	// Wait for simulation to complete
-	time.Sleep(simulationDuration)
+	result := report.StrategyResult{
+		Strategy:           name,
+		Metrics:            orch.Summary(),
+		QoSViolations:      orch.Stats.QoSViolations,
+		Migrations:         orch.Stats.Migrations,
+		CooldownSuppressed: orch.Stats.SuppressedMigrations["cooldown"],
+		BudgetSuppressed:   orch.Stats.SuppressedMigrations["budget"],
+		JobGPUCounts:       orch.Stats.JobGPUCounts,
+		DistributedJobs:    orch.Stats.DistributedStarts,
+		Reconfigurations:   orch.Stats.Reconfigurations,
+		SlowdownViolations: orch.Stats.SlowdownViolations,
+		IOThrottled:        orch.Stats.IOThrottled,
+		Unschedulable:      orch.Stats.Unschedulable,
+		SuboptimalTopology: orch.Stats.SuboptimalTopology,
+	}
+	if len(orch.Stats.TopologyStarts) > 0 {
+		result.TopologyLinks = map[string]int{}
+		for link, count := range orch.Stats.TopologyStarts {
+			result.TopologyLinks[link.String()] = count
+		}
+	}
+	if concentration := orch.DomainConcentration(); len(concentration) > 0 {
+		result.DomainConcentration = map[string]float64{}
+		for level, share := range concentration {
+			result.DomainConcentration[level.String()] = share
+		}
+	}
+	result.AddContainers(cluster.Containers, eng.Now())
+	result.AddEnergy(orch.Stats.Energy, orch.Stats.HostEnergy, time.Duration(sc.Duration))
+	if len(b.Budgets) > 0 {
+		result.AddPowerCap(orch.Stats.PowerCapRefused, orch.Stats.ClockThrottled, orch.Stats.GPUBusyTime,
+			orch.Stats.GPUThrottledTime, orch.Stats.OverBudgetTime, orch.Stats.PeakBudgetUse)
+	}
+	if orch.Consolidation != nil {
+		result.AddConsolidation(orch.Stats.HostsDrained, orch.Stats.PowerDowns, orch.Stats.Boots,
+			orch.Stats.HostOffTime, len(cluster.Hosts), time.Duration(sc.Duration), orch.Stats.EnergySavedWh)
+	}
+	result.AddGangWaits(orch.Stats.GangWaits, orch.Stats.GangsWaiting, orch.Stats.GangTimeouts)
+	if orch.Network != nil {
+		result.AddNetwork(orch.Stats.MigrationTraffic, orch.Stats.DatasetTraffic,
+			orch.Stats.MigrationTransfers, orch.Stats.DatasetWaits, orch.Stats.NetworkThrottled)
+	}
+	if orch.Network != nil || orch.Migration != nil {
+		result.AddMigrations(orch.Stats.MigrationTraffic, orch.Stats.MigrationTransfers,
+			orch.Stats.Downtimes, orch.Stats.AbortedMigrations)
+	}
 
 	// Print final metrics and QoS status
 	finalMetrics := b.GetCurrentMetrics()
@@ -86,71 +252,30 @@ func runSimulation(name string, strategy scheduler.Scheduler, hosts []*models.Ho
 	}
 
 	logger.Printf("%s simulation complete.\n", name)
+	return result
 }
 
-func simulateWorkloadChanges(orch *orchestrator.Orchestrator, duration time.Duration, logger *log.Logger) {
-	ticker := time.NewTicker(workloadChangeInterval)
-	defer ticker.Stop()
-
-	end := time.Now().Add(duration)
-
-	for {
-		select {
-		case <-ticker.C:
-			// Simulate random workload changes
-			for _, host := range orch.Broker.Hosts {
-				for _, container := range host.Containers {
-					container.CPURequest = int(float64(container.CPURequest) * (0.8 + rand.Float64()*0.4))       // +/- 20%
-					container.MemoryRequest = int(float64(container.MemoryRequest) * (0.8 + rand.Float64()*0.4)) // +/- 20%
-				}
-			}
-			logger.Println("Workload changed. Triggering reallocation...")
-			orch.TriggerReallocation()
-		default:
-			if time.Now().After(end) {
-				return
-			}
-			time.Sleep(time.Second)
-		}
-	}
-}
-
-func createHosts() []*models.Host {
-	hosts := make([]*models.Host, numHosts)
-	for i := 0; i < numHosts; i++ {
-		hosts[i] = models.NewHost(fmt.Sprintf("host-%d", i+1), 32+rand.Intn(128), 65536+rand.Intn(524288))
-	}
-	return hosts
-}
-
-func createGPUs() []*models.GPU {
-	gpus := make([]*models.GPU, numGPUs)
-	for i := 0; i < numGPUs; i++ {
-		gpus[i] = models.NewGPU(fmt.Sprintf("gpu-%d", i+1), 3584+rand.Intn(8192), 224+rand.Intn(512), 8192+rand.Intn(49152), 900+rand.Intn(2400), 13.4+rand.Float64()*18.7, 250+rand.Intn(250))
-	}
-	return gpus
-}
-
-func createContainers(gpus []*models.GPU) []*models.Container {
-	containers := make([]*models.Container, numContainers)
-	for i := 0; i < numContainers; i++ {
-		containers[i] = models.NewContainer(fmt.Sprintf("container-%d", i+1), 1000+rand.Intn(16000), 2048+rand.Intn(32768), gpus[rand.Intn(len(gpus))], 1+rand.Intn(3))
-	}
-	return containers
+func simulateWorkloadChanges(orch *orchestrator.Orchestrator, rng *rand.Rand, interval time.Duration, logger *log.Logger) {
+	orch.Engine.Every(interval, func() {
+		// Simulate random workload changes
+		orch.Resize(func(container *models.Container) (int, int) {
+			cpuRequest := int(float64(container.CPURequest) * (0.8 + rng.Float64()*0.4))       // +/- 20%
+			memoryRequest := int(float64(container.MemoryRequest) * (0.8 + rng.Float64()*0.4)) // +/- 20%
+			return cpuRequest, memoryRequest
+		})
+		logger.Println("Workload changed. Triggering reallocation...")
+		orch.TriggerReallocation()
+	})
 }
 
-func createQoSMonitor() *qos.QoS {
-	cpuThreshold := 80.0    // CPU usage threshold in percentage
-	memoryThreshold := 85.0 // Memory usage threshold in percentage
-	gpuThreshold := 95.0    // GPU usage threshold in percentage
-	ioThreshold := 75.0     // IO usage threshold in percentage
-	return qos.NewQoS(cpuThreshold, memoryThreshold, gpuThreshold, ioThreshold)
+func createQoSMonitor(thresholds scenario.QoSThresholds) *qos.QoS {
+	return qos.NewQoS(thresholds.CPU, thresholds.Memory, thresholds.GPU, thresholds.IO, thresholds.MaxSlowdown)
 }
 
-func setupLogger(filename string) *log.Logger {
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func setupLogger(filename string, eng *engine.Engine) *log.Logger {
+	file, err := os.OpenFile(filename, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Fatalf("Error opening log file %s: %v", filename, err)
 	}
-	return log.New(file, "", log.LstdFlags)
+	return engine.NewLogger(file, eng)
 }
@@ -0,0 +1,50 @@
+package models
+
+// Cluster is a self-contained snapshot of hosts and the containers to run on
+// them.
+type Cluster struct {
+	Hosts      []*Host
+	Containers []*Container
+	// Jobs groups some of the containers; every job member is also listed
+	// in Containers.
+	Jobs []*Job
+}
+
+func NewCluster(hosts []*Host, containers []*Container) *Cluster {
+	return &Cluster{
+		Hosts:      hosts,
+		Containers: containers,
+	}
+}
+
+// Clone returns a deep copy of the cluster that shares no mutable state with
+// the original. Containers already placed on a host are the same objects as
+// the corresponding entries in the cloned Containers slice.
+func (c *Cluster) Clone() *Cluster {
+	clonedContainers := make(map[string]*Container, len(c.Containers))
+	cloned := &Cluster{
+		Hosts:      make([]*Host, len(c.Hosts)),
+		Containers: make([]*Container, len(c.Containers)),
+	}
+
+	for i, container := range c.Containers {
+		cloned.Containers[i] = container.Clone()
+		clonedContainers[container.ID] = cloned.Containers[i]
+	}
+
+	for i, host := range c.Hosts {
+		clonedHost := host.Clone()
+		for j, container := range clonedHost.Containers {
+			if shared, ok := clonedContainers[container.ID]; ok {
+				clonedHost.Containers[j] = shared
+			}
+		}
+		cloned.Hosts[i] = clonedHost
+	}
+
+	for _, job := range c.Jobs {
+		cloned.Jobs = append(cloned.Jobs, job.Clone(clonedContainers))
+	}
+
+	return cloned
+}
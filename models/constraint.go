@@ -0,0 +1,330 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Constraint restricts which GPUs may serve a container, as a conjunction of
+// conditions on GPU attributes (see GPU.Attribute), e.g.
+//
+//	compute_capability >= 8.0 && fp8 && driver_version >= 535.104
+//	architecture in (Hopper, "Ada Lovelace") && !mig
+//
+// A bare attribute requires it to be set and true; "!" requires the
+// opposite. Comparisons are by component when either side is a dotted
+// version such as a driver version, so 535.104 is above 535.86; numeric when
+// both sides are numbers or the attribute is one of the GPU's numeric
+// measures such as tflops; and otherwise only == and != are defined. The
+// zero Constraint matches every GPU.
+type Constraint struct {
+	source  string
+	clauses []clause
+}
+
+type clause struct {
+	attribute string
+	op        string // "", "!", "==", "!=", "<", "<=", ">", ">=" or "in"
+	values    []string
+}
+
+// ParseConstraint parses a constraint expression. An empty expression
+// yields the zero Constraint.
+func ParseConstraint(expr string) (Constraint, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return Constraint{}, err
+	}
+	c := Constraint{source: strings.TrimSpace(expr)}
+	p := &parser{tokens: tokens}
+	for !p.done() {
+		if len(c.clauses) > 0 && !p.accept("&&") {
+			return Constraint{}, fmt.Errorf("expected && before %q", p.peek())
+		}
+		cl, err := p.clause()
+		if err != nil {
+			return Constraint{}, err
+		}
+		c.clauses = append(c.clauses, cl)
+	}
+	return c, nil
+}
+
+func (c Constraint) String() string {
+	return c.source
+}
+
+// IsZero reports whether the constraint has no conditions.
+func (c Constraint) IsZero() bool {
+	return len(c.clauses) == 0
+}
+
+// Attributes returns the attributes the constraint refers to.
+func (c Constraint) Attributes() []string {
+	attributes := make([]string, len(c.clauses))
+	for i, cl := range c.clauses {
+		attributes[i] = cl.attribute
+	}
+	return attributes
+}
+
+// Matches reports whether the GPU meets every condition.
+func (c Constraint) Matches(g *GPU) bool {
+	return c.Explain(g) == ""
+}
+
+// Explain returns the first condition the GPU does not meet, or "" if it
+// meets them all.
+func (c Constraint) Explain(g *GPU) string {
+	for _, cl := range c.clauses {
+		if !cl.matches(g) {
+			return cl.String()
+		}
+	}
+	return ""
+}
+
+func (cl clause) matches(g *GPU) bool {
+	value, ok := g.Attribute(cl.attribute)
+	switch cl.op {
+	case "":
+		return ok && truthy(value)
+	case "!":
+		return !ok || !truthy(value)
+	case "in":
+		for _, v := range cl.values {
+			if ok && compare(cl.attribute, value, v) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if !ok {
+		return false
+	}
+	cmp := compare(cl.attribute, value, cl.values[0])
+	switch cl.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp == -1
+	case "<=":
+		return cmp == -1 || cmp == 0
+	case ">":
+		return cmp == 1
+	case ">=":
+		return cmp == 1 || cmp == 0
+	}
+	return false
+}
+
+func (cl clause) String() string {
+	switch cl.op {
+	case "":
+		return cl.attribute
+	case "!":
+		return "!" + cl.attribute
+	case "in":
+		return fmt.Sprintf("%s in (%s)", cl.attribute, strings.Join(cl.values, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", cl.attribute, cl.op, cl.values[0])
+}
+
+func truthy(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// numericAttributes are the built-in attributes whose values are plain
+// numbers, so 19.5 TFLOPS stays above 19.25 rather than being read as a
+// version.
+var numericAttributes = map[string]bool{
+	"fp16_tflops": true, "tflops": true, "vram": true, "memory_bandwidth": true,
+}
+
+// compare orders two values of the attribute, returning -1, 0 or 1, or 2
+// when they cannot be ordered (non-numeric values that differ).
+func compare(attribute, a, b string) int {
+	if !numericAttributes[attribute] {
+		if x, ok := parseVersion(a); ok {
+			if y, ok := parseVersion(b); ok && (len(x) > 1 || len(y) > 1) {
+				return compareVersions(x, y)
+			}
+		}
+	}
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			return sign(x - y)
+		}
+	}
+	if strings.EqualFold(a, b) {
+		return 0
+	}
+	return 2
+}
+
+// compareVersions orders two versions component by component, treating
+// missing trailing components as zero.
+func compareVersions(x, y []int) int {
+	for i := 0; i < max(len(x), len(y)); i++ {
+		var xi, yi int
+		if i < len(x) {
+			xi = x[i]
+		}
+		if i < len(y) {
+			yi = y[i]
+		}
+		if xi != yi {
+			return sign(float64(xi - yi))
+		}
+	}
+	return 0
+}
+
+func sign(v float64) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+func parseVersion(s string) ([]int, bool) {
+	parts := strings.Split(s, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) accept(token string) bool {
+	if p.peek() == token {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) value() (string, error) {
+	token := p.peek()
+	if token == "" || isOperator(token) {
+		return "", fmt.Errorf("expected a value, got %q", token)
+	}
+	p.pos++
+	return strings.Trim(token, `"`), nil
+}
+
+func (p *parser) clause() (clause, error) {
+	if p.accept("!") {
+		attribute, err := p.value()
+		return clause{attribute: attribute, op: "!"}, err
+	}
+	attribute, err := p.value()
+	if err != nil {
+		return clause{}, err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.pos++
+		v, err := p.value()
+		return clause{attribute: attribute, op: op, values: []string{v}}, err
+	case "in":
+		p.pos++
+		if !p.accept("(") {
+			return clause{}, fmt.Errorf("expected ( after in")
+		}
+		cl := clause{attribute: attribute, op: "in"}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return clause{}, err
+			}
+			cl.values = append(cl.values, v)
+			if p.accept(")") {
+				return cl, nil
+			}
+			if !p.accept(",") {
+				return clause{}, fmt.Errorf("expected , or ) in the list for %s", attribute)
+			}
+		}
+	}
+	return clause{attribute: attribute}, nil
+}
+
+func isOperator(token string) bool {
+	switch token {
+	case "&&", "!", "==", "!=", "<", "<=", ">", ">=", "in", "(", ")", ",":
+		return true
+	}
+	return false
+}
+
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		ch := rune(expr[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, expr[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("(),", ch):
+			tokens = append(tokens, string(ch))
+			i++
+		case strings.ContainsRune("&!=<>", ch):
+			op := string(ch)
+			if i+1 < len(expr) && strings.ContainsRune("&=", rune(expr[i+1])) {
+				op = expr[i : i+2]
+			}
+			switch op {
+			case "&&", "!", "==", "!=", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("unknown operator %q at offset %d", op, i)
+			}
+			tokens = append(tokens, op)
+			i += len(op)
+		default:
+			start := i
+			for i < len(expr) && !unicode.IsSpace(rune(expr[i])) && !strings.ContainsRune(`(),&!=<>"`, rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		}
+	}
+	return tokens, nil
+}
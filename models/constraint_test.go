@@ -0,0 +1,210 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []clause
+	}{
+		{expr: "", want: nil},
+		{expr: "  ", want: nil},
+		{expr: "fp8", want: []clause{{attribute: "fp8"}}},
+		{expr: "!mig", want: []clause{{attribute: "mig", op: "!"}}},
+		{expr: "vram>=40960", want: []clause{{attribute: "vram", op: ">=", values: []string{"40960"}}}},
+		{
+			expr: "compute_capability >= 8.0 && fp8 && driver_version >= 535.104",
+			want: []clause{
+				{attribute: "compute_capability", op: ">=", values: []string{"8.0"}},
+				{attribute: "fp8"},
+				{attribute: "driver_version", op: ">=", values: []string{"535.104"}},
+			},
+		},
+		{
+			// Comparisons bind tighter than &&, and ! only to the attribute
+			// that follows it.
+			expr: `!shared && model != H100 && tflops < 60`,
+			want: []clause{
+				{attribute: "shared", op: "!"},
+				{attribute: "model", op: "!=", values: []string{"H100"}},
+				{attribute: "tflops", op: "<", values: []string{"60"}},
+			},
+		},
+		{
+			expr: `architecture in (Hopper, "Ada Lovelace") && !mig`,
+			want: []clause{
+				{attribute: "architecture", op: "in", values: []string{"Hopper", "Ada Lovelace"}},
+				{attribute: "mig", op: "!"},
+			},
+		},
+		{
+			expr: `rack == "a&&b"`,
+			want: []clause{{attribute: "rack", op: "==", values: []string{"a&&b"}}},
+		},
+		{
+			expr: `model in (L4)`,
+			want: []clause{{attribute: "model", op: "in", values: []string{"L4"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseConstraint(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseConstraint: %v", err)
+			}
+			if !reflect.DeepEqual(c.clauses, tt.want) {
+				t.Errorf("clauses = %+v, want %+v", c.clauses, tt.want)
+			}
+			if c.IsZero() != (len(tt.want) == 0) {
+				t.Errorf("IsZero() = %v", c.IsZero())
+			}
+			if c.String() != strings.TrimSpace(tt.expr) {
+				t.Errorf("String() = %q, want the trimmed source", c.String())
+			}
+		})
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "vram >=", want: `expected a value, got ""`},
+		{expr: "== 4", want: `expected a value, got "=="`},
+		{expr: "fp8 mig", want: `expected && before "mig"`},
+		{expr: "!vram >= 10", want: `expected && before ">="`},
+		{expr: "fp8 &&", want: `expected a value, got ""`},
+		{expr: "fp8 & mig", want: `unknown operator "&" at offset 4`},
+		{expr: "vram => 10", want: `unknown operator "=" at offset 5`},
+		{expr: `model == "H100`, want: "unterminated string at offset 9"},
+		{expr: "model in H100", want: "expected ( after in"},
+		{expr: "model in (H100 L4)", want: "expected , or ) in the list for model"},
+		{expr: "model in (H100,", want: `expected a value, got ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseConstraint(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseConstraint succeeded, want %q", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintMatches(t *testing.T) {
+	gpu := NewGPU("gpu-1", 16896, 528, 81920, 3350, 67, 700)
+	gpu.Model = "H100"
+	gpu.Architecture = "Hopper"
+	gpu.ComputeCapability = "9.0"
+	gpu.FP16TFLOPS = 989.5
+	gpu.FP8TFLOPS = 1979
+	gpu.TensorCoreGeneration = 4
+	gpu.Labels = map[string]string{
+		"driver_version": "535.104",
+		"cuda":           "12.2.1",
+		"zone":           "Eu-West",
+		"spot":           "no",
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// Truthiness and negation.
+		{"fp8", true},
+		{"!fp8", false},
+		{"mig", false},
+		{"!mig", true},
+		{"spot", false},
+		{"!rdma", true},
+		{"rdma", false},
+
+		// Numbers.
+		{"vram >= 81920", true},
+		{"vram > 81920", false},
+		{"vram < 100000", true},
+		{"tflops <= 67", true},
+		{"tflops == 67.0", true},
+		{"fp16_tflops > 989.25", true},
+		{"fp16_tflops < 989.25", false},
+		{"tensor_core_generation != 4", false},
+
+		// Versions compare by component, not as decimals.
+		{"driver_version >= 535.86", true},
+		{"driver_version > 535.86", true},
+		{"driver_version < 535.86", false},
+		{"driver_version >= 535.104", true},
+		{"driver_version == 535.104.0", true},
+		{"driver_version >= 550", false},
+		{"driver_version < 550", true},
+		{"driver_version >= 535", true},
+		{"cuda >= 12.2", true},
+		{"cuda < 12.10", true},
+		{"compute_capability >= 8.0", true},
+		{"compute_capability >= 10.0", false},
+		{"compute_capability in (8.0, 9.0)", true},
+
+		// Strings only support equality, case-insensitively.
+		{"model == H100", true},
+		{"model == h100", true},
+		{"model != A100", true},
+		{`zone == "eu-west"`, true},
+		{"model < Z100", false},
+		{"model >= H100", true},
+		{`architecture in (Ampere, "Ada Lovelace")`, false},
+		{`architecture in (Ampere, Hopper)`, true},
+
+		// A comparison on a missing attribute never matches.
+		{"rack == a", false},
+		{"rack != a", false},
+		{"rack in (a, b)", false},
+
+		// Conjunctions.
+		{"fp8 && !mig && driver_version >= 535.86", true},
+		{"fp8 && mig", false},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseConstraint(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseConstraint: %v", err)
+			}
+			if got := c.Matches(gpu); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintExplain(t *testing.T) {
+	gpu := NewGPU("gpu-1", 7424, 240, 24576, 300, 30, 72)
+	gpu.Labels = map[string]string{"driver_version": "525.60.13"}
+
+	c, err := ParseConstraint(`vram >= 16384 && driver_version >= 535.86 && model in (L4, "RTX 4000")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Explain(gpu), "driver_version >= 535.86"; got != want {
+		t.Errorf("Explain = %q, want %q", got, want)
+	}
+	if got, want := c.Attributes(), []string{"vram", "driver_version", "model"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes = %v, want %v", got, want)
+	}
+
+	gpu.Labels["driver_version"] = "550.54.14"
+	if got, want := c.Explain(gpu), "model in (L4, RTX 4000)"; got != want {
+		t.Errorf("Explain = %q, want %q", got, want)
+	}
+}
@@ -1,29 +1,214 @@
 package models
 
+import (
+	"slices"
+	"time"
+)
+
+// ContainerState is the lifecycle state of a container.
+type ContainerState int
+
+const (
+	Pending ContainerState = iota
+	Running
+	Migrating
+	Completed
+	Failed
+	Preempted
+)
+
+func (s ContainerState) String() string {
+	switch s {
+	case Pending:
+		return "Pending"
+	case Running:
+		return "Running"
+	case Migrating:
+		return "Migrating"
+	case Completed:
+		return "Completed"
+	case Failed:
+		return "Failed"
+	case Preempted:
+		return "Preempted"
+	}
+	return "Unknown"
+}
+
 type Container struct {
 	ID            string
 	CPURequest    int // in millicores
 	MemoryRequest int // in MB
-	GPURequest    *GPU
-	Priority      int // Priority for scheduling
+	GPURequest    GPURequirement
+	Priority      int    // Priority for scheduling
+	JobID         string // job the container belongs to, if any
+	// DiskIO and NetworkIO are the I/O throughput the container needs to
+	// run at full speed, in MB/s. I/O is not reserved: hosts share their
+	// bandwidth and contention slows containers down.
+	DiskIO    int
+	NetworkIO int
+	// DatasetSize is the data in MB the container fetches from storage to
+	// each of its hosts before it starts. SyncTraffic is the rate in MB/s
+	// at which a multi-host container, or each worker of a gang job,
+	// exchanges gradients with its peers on other hosts.
+	DatasetSize int
+	SyncTraffic int
+
+	// Group names the placement group the container's Placement rules are
+	// relative to, e.g. the replicas of one service or the workers of one
+	// job.
+	Group     string
+	Placement []PlacementRule
+
+	// HostID is the host the container is placed on and GPUBindings lists
+	// the physical GPUs it currently runs on. Both are empty while the
+	// container is not placed.
+	HostID      string
+	GPUBindings []GPUBinding
+	// SetupDelay is how long the container waits after placement before it
+	// can run, e.g. while a GPU is reconfigured for its MIG partition.
+	SetupDelay time.Duration
+	// Reason says why the container is not placed while it is pending, or
+	// why it failed.
+	Reason string
+
+	// Lifecycle, in simulated time since the start of the run.
+	SubmitTime      time.Duration
+	ExpectedRuntime time.Duration // zero means the container runs until the simulation ends
+	StartTime       time.Duration
+	EndTime         time.Duration
+	State           ContainerState
+
+	// Progress is the work done so far, measured as runtime at full speed.
+	// Slowdown is the factor by which contention for shared GPUs and I/O
+	// currently stretches the container's runtime and MaxSlowdown the worst
+	// it has seen.
+	Progress    time.Duration
+	Slowdown    float64
+	MaxSlowdown float64
+	// Downtime is how long migrations have paused the container.
+	Downtime time.Duration
+	// Energy is the energy in Wh attributed to the container from its
+	// hosts' power draw.
+	Energy float64
+	// Throttled is how long power-capped GPU clocks slowed the container.
+	Throttled time.Duration
 }
 
-func NewContainer(id string, cpuRequest, memoryRequest int, gpuRequest *GPU, priority int) *Container {
+func NewContainer(id string, cpuRequest, memoryRequest int, gpuRequest GPURequirement, priority int) *Container {
 	return &Container{
 		ID:            id,
 		CPURequest:    cpuRequest,
 		MemoryRequest: memoryRequest,
 		GPURequest:    gpuRequest,
 		Priority:      priority,
+		State:         Pending,
 	}
 }
 
 func (c *Container) Clone() *Container {
 	return &Container{
-		ID:            c.ID,
-		CPURequest:    c.CPURequest,
-		MemoryRequest: c.MemoryRequest,
-		GPURequest:    c.GPURequest.Clone(),
-		Priority:      c.Priority,
+		ID:              c.ID,
+		CPURequest:      c.CPURequest,
+		MemoryRequest:   c.MemoryRequest,
+		GPURequest:      c.GPURequest.Clone(),
+		Priority:        c.Priority,
+		JobID:           c.JobID,
+		DiskIO:          c.DiskIO,
+		NetworkIO:       c.NetworkIO,
+		DatasetSize:     c.DatasetSize,
+		SyncTraffic:     c.SyncTraffic,
+		Group:           c.Group,
+		Placement:       c.Placement,
+		HostID:          c.HostID,
+		GPUBindings:     append([]GPUBinding(nil), c.GPUBindings...),
+		SetupDelay:      c.SetupDelay,
+		Reason:          c.Reason,
+		SubmitTime:      c.SubmitTime,
+		ExpectedRuntime: c.ExpectedRuntime,
+		StartTime:       c.StartTime,
+		EndTime:         c.EndTime,
+		State:           c.State,
+		Progress:        c.Progress,
+		Slowdown:        c.Slowdown,
+		MaxSlowdown:     c.MaxSlowdown,
+		Downtime:        c.Downtime,
+		Energy:          c.Energy,
+		Throttled:       c.Throttled,
+	}
+}
+
+// IsActive reports whether the container currently holds resources on a host.
+func (c *Container) IsActive() bool {
+	return c.State == Running || c.State == Migrating
+}
+
+// AvgSlowdown returns how much longer than at full speed the container has
+// taken for the work it has done so far.
+func (c *Container) AvgSlowdown(now time.Duration) float64 {
+	if c.Progress <= 0 {
+		return 1
+	}
+	return max(float64(c.ActualRuntime(now))/float64(c.Progress), 1)
+}
+
+// ActualRuntime is how long the container has run, or ran if it has finished.
+func (c *Container) ActualRuntime(now time.Duration) time.Duration {
+	switch c.State {
+	case Running, Migrating:
+		return now - c.StartTime
+	case Completed, Failed, Preempted:
+		if c.EndTime > c.StartTime {
+			return c.EndTime - c.StartTime
+		}
+	}
+	return 0
+}
+
+// QueueDelay is how long the container waited between submission and start.
+// A container that has not started yet has been waiting since submission; one
+// that failed before starting waited until it failed.
+func (c *Container) QueueDelay(now time.Duration) time.Duration {
+	if c.State == Pending {
+		return max(now-c.SubmitTime, 0)
+	}
+	return c.StartTime - c.SubmitTime
+}
+
+// GPUIDs returns the IDs of the GPUs the container is bound to.
+func (c *Container) GPUIDs() []string {
+	ids := make([]string, len(c.GPUBindings))
+	for i, binding := range c.GPUBindings {
+		ids[i] = binding.GPUID
+	}
+	return ids
+}
+
+// HostIDs returns every host the container holds resources on, starting with
+// its primary host.
+func (c *Container) HostIDs() []string {
+	if c.HostID == "" {
+		return nil
+	}
+	ids := []string{c.HostID}
+	for _, binding := range c.GPUBindings {
+		if !slices.Contains(ids, binding.HostID) {
+			ids = append(ids, binding.HostID)
+		}
+	}
+	return ids
+}
+
+// IsDistributed reports whether the container is spread over several hosts.
+func (c *Container) IsDistributed() bool {
+	return len(c.HostIDs()) > 1
+}
+
+func (c *Container) boundTo(hostID, gpuID string) bool {
+	for _, binding := range c.GPUBindings {
+		if binding.HostID == hostID && binding.GPUID == gpuID {
+			return true
+		}
 	}
+	return false
 }
@@ -1,13 +1,48 @@
 package models
 
+import (
+	"fmt"
+	"strconv"
+)
+
 type GPU struct {
 	ID               string
+	Model            string
 	CUDACores        int
 	TensorCores      int
 	VRAM             int // in MB
 	MemoryBandwidth  int // in GB/s
 	TFLOPS           float64
-	PowerConsumption int // in watts
+	PowerConsumption int // in watts, at full utilization
+	IdlePower        int // in watts, with nothing running
+
+	Architecture         string
+	ComputeCapability    string  // e.g. "8.0"
+	TensorCoreGeneration int     // zero when the GPU has no tensor cores
+	FP16TFLOPS           float64 // dense tensor throughput
+	FP8TFLOPS            float64 // zero when FP8 is not supported
+
+	// Labels are free-form attributes such as the installed driver version.
+	Labels map[string]string
+
+	// Capacity currently handed out to containers.
+	AllocatedCUDACores       int
+	AllocatedVRAM            int // in MB
+	AllocatedMemoryBandwidth int // in GB/s
+
+	// MIG is the partition layout of a MIG-enabled GPU, nil otherwise. A
+	// MIG-enabled GPU only serves requests for a partition profile.
+	MIG *MIGLayout
+
+	// Sharing lets several containers share the GPU, nil otherwise. Tenants
+	// counts the containers currently bound to a shared GPU.
+	Sharing *GPUSharing
+	Tenants int
+
+	// DVFS is the GPU's clock curve, DefaultDVFS when nil, and ClockState
+	// the index of the state a power cap holds it at; 0 is full clock.
+	DVFS       []DVFSState
+	ClockState int
 }
 
 func NewGPU(id string, cudaCores, tensorCores, vram, memoryBandwidth int, tflops float64, powerConsumption int) *GPU {
@@ -23,13 +58,176 @@ func NewGPU(id string, cudaCores, tensorCores, vram, memoryBandwidth int, tflops
 }
 
 func (g *GPU) Clone() *GPU {
-	return &GPU{
-		ID:               g.ID,
-		CUDACores:        g.CUDACores,
-		TensorCores:      g.TensorCores,
-		VRAM:             g.VRAM,
-		MemoryBandwidth:  g.MemoryBandwidth,
-		TFLOPS:           g.TFLOPS,
-		PowerConsumption: g.PowerConsumption,
+	cloned := &GPU{
+		ID:                       g.ID,
+		Model:                    g.Model,
+		CUDACores:                g.CUDACores,
+		TensorCores:              g.TensorCores,
+		VRAM:                     g.VRAM,
+		MemoryBandwidth:          g.MemoryBandwidth,
+		TFLOPS:                   g.TFLOPS,
+		PowerConsumption:         g.PowerConsumption,
+		IdlePower:                g.IdlePower,
+		Architecture:             g.Architecture,
+		ComputeCapability:        g.ComputeCapability,
+		TensorCoreGeneration:     g.TensorCoreGeneration,
+		FP16TFLOPS:               g.FP16TFLOPS,
+		FP8TFLOPS:                g.FP8TFLOPS,
+		AllocatedCUDACores:       g.AllocatedCUDACores,
+		AllocatedVRAM:            g.AllocatedVRAM,
+		AllocatedMemoryBandwidth: g.AllocatedMemoryBandwidth,
+		Sharing:                  g.Sharing, // never modified during a run
+		Tenants:                  g.Tenants,
+		DVFS:                     g.DVFS, // never modified during a run
+		ClockState:               g.ClockState,
+	}
+	if g.Labels != nil {
+		cloned.Labels = make(map[string]string, len(g.Labels))
+		for k, v := range g.Labels {
+			cloned.Labels[k] = v
+		}
+	}
+	if g.MIG != nil {
+		cloned.MIG = g.MIG.Clone()
+	}
+	return cloned
+}
+
+// BuiltinAttributes lists the attributes every GPU has, in addition to its
+// labels.
+var BuiltinAttributes = []string{
+	"model", "architecture", "compute_capability", "tensor_core_generation",
+	"fp8", "fp16_tflops", "tflops", "vram", "memory_bandwidth", "mig", "shared",
+}
+
+// Attribute returns the value of a built-in attribute or label of the GPU,
+// as matched by constraints.
+func (g *GPU) Attribute(name string) (string, bool) {
+	switch name {
+	case "model":
+		return g.Model, g.Model != ""
+	case "architecture":
+		return g.Architecture, g.Architecture != ""
+	case "compute_capability":
+		return g.ComputeCapability, g.ComputeCapability != ""
+	case "tensor_core_generation":
+		return strconv.Itoa(g.TensorCoreGeneration), true
+	case "fp8":
+		return strconv.FormatBool(g.FP8TFLOPS > 0), true
+	case "fp16_tflops":
+		return strconv.FormatFloat(g.FP16TFLOPS, 'f', -1, 64), true
+	case "tflops":
+		return strconv.FormatFloat(g.TFLOPS, 'f', -1, 64), true
+	case "vram":
+		return strconv.Itoa(g.VRAM), true
+	case "memory_bandwidth":
+		return strconv.Itoa(g.MemoryBandwidth), true
+	case "mig":
+		return strconv.FormatBool(g.MIG != nil), true
+	case "shared":
+		return strconv.FormatBool(g.Sharing != nil), true
+	}
+	value, ok := g.Labels[name]
+	return value, ok
+}
+
+func (g *GPU) FreeCUDACores() int {
+	return g.CUDACores - g.AllocatedCUDACores
+}
+
+func (g *GPU) FreeVRAM() int {
+	return g.VRAM - g.AllocatedVRAM
+}
+
+func (g *GPU) FreeMemoryBandwidth() int {
+	return g.MemoryBandwidth - g.AllocatedMemoryBandwidth
+}
+
+// Fits reports whether the GPU meets the request's constraints and its free
+// capacity covers the request's per-GPU amounts. A request for a MIG profile
+// fits only a MIG-enabled GPU with room for such a partition. A shared GPU
+// only needs a free tenant slot and enough free VRAM, since tenants' compute
+// and bandwidth may be oversubscribed.
+func (g *GPU) Fits(request GPURequirement) bool {
+	if !request.Accepts(g) {
+		return false
+	}
+	if request.Profile != "" || g.MIG != nil {
+		return request.Profile != "" && g.MIG != nil && g.MIG.Fits(request.Profile)
+	}
+	if g.Sharing != nil {
+		return g.Tenants < g.Sharing.MaxTenants && g.FreeVRAM() >= request.VRAM
+	}
+	return g.FreeCUDACores() >= request.CUDACores &&
+		g.FreeVRAM() >= request.VRAM &&
+		g.FreeMemoryBandwidth() >= request.MemoryBandwidth
+}
+
+// Allocate reserves the requested capacity, refusing to overcommit.
+func (g *GPU) Allocate(request GPURequirement) error {
+	if request.Profile != "" {
+		return fmt.Errorf("GPU %s: %s partitions must be allocated with AllocatePartition", g.ID, request.Profile)
+	}
+	if !g.Fits(request) {
+		return fmt.Errorf("GPU %s has insufficient free capacity", g.ID)
+	}
+	g.AllocatedCUDACores += request.CUDACores
+	g.AllocatedVRAM += request.VRAM
+	g.AllocatedMemoryBandwidth += request.MemoryBandwidth
+	if g.Sharing != nil {
+		g.Tenants++
+	}
+	return nil
+}
+
+// Release returns previously allocated capacity.
+func (g *GPU) Release(request GPURequirement) {
+	g.AllocatedCUDACores = max(g.AllocatedCUDACores-request.CUDACores, 0)
+	g.AllocatedVRAM = max(g.AllocatedVRAM-request.VRAM, 0)
+	g.AllocatedMemoryBandwidth = max(g.AllocatedMemoryBandwidth-request.MemoryBandwidth, 0)
+	if g.Sharing != nil {
+		g.Tenants = max(g.Tenants-1, 0)
+	}
+}
+
+// AllocatePartition hands the container a partition of the requested MIG
+// profile and reserves that partition's share of the GPU's capacity.
+// reconfigured reports whether the GPU's layout had to change.
+func (g *GPU) AllocatePartition(request GPURequirement, containerID string) (partition *MIGPartition, reconfigured bool, err error) {
+	if !g.Fits(request) {
+		return nil, false, fmt.Errorf("GPU %s cannot serve a %s partition", g.ID, request.Profile)
+	}
+	partition, reconfigured, err = g.MIG.Assign(request.Profile, containerID)
+	if err != nil {
+		return nil, false, fmt.Errorf("GPU %s: %w", g.ID, err)
 	}
+	cores, vram, bandwidth := g.partitionShare(partition)
+	g.AllocatedCUDACores += cores
+	g.AllocatedVRAM += vram
+	g.AllocatedMemoryBandwidth += bandwidth
+	return partition, reconfigured, nil
+}
+
+// ReleasePartition frees the container's partition, which stays configured.
+func (g *GPU) ReleasePartition(containerID string) {
+	if g.MIG == nil {
+		return
+	}
+	partition := g.MIG.Unassign(containerID)
+	if partition == nil {
+		return
+	}
+	cores, vram, bandwidth := g.partitionShare(partition)
+	g.AllocatedCUDACores = max(g.AllocatedCUDACores-cores, 0)
+	g.AllocatedVRAM = max(g.AllocatedVRAM-vram, 0)
+	g.AllocatedMemoryBandwidth = max(g.AllocatedMemoryBandwidth-bandwidth, 0)
+}
+
+// partitionShare is the part of the GPU's capacity a partition owns: its
+// compute slices' share of the cores and bandwidth and its profile's memory.
+func (g *GPU) partitionShare(p *MIGPartition) (cores, vram, bandwidth int) {
+	profile, _ := g.MIG.Profile(p.Profile)
+	cores = g.CUDACores * p.Slices / g.MIG.Slices
+	bandwidth = g.MemoryBandwidth * p.Slices / g.MIG.Slices
+	return cores, profile.Memory, bandwidth
 }
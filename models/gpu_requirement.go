@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// GPURequirement describes what GPU resources a container needs, independent
+// of which physical devices end up serving it. The per-GPU amounts are
+// reserved on each of the Count GPUs the container is bound to.
+type GPURequirement struct {
+	Count           int     // number of GPUs; zero means no GPU is needed
+	CUDACores       int     // per GPU
+	VRAM            int     // per GPU, in MB
+	MemoryBandwidth int     // per GPU, in GB/s
+	MinTFLOPS       float64 // minimum device compute
+	// MultiHost allows the GPUs to be spread over several hosts when no
+	// single host has Count free GPUs.
+	MultiHost bool
+	// Models restricts placement to the listed GPU models; empty means any.
+	Models []string
+	// Constraint restricts placement to GPUs whose attributes match it.
+	Constraint Constraint
+	// Profile requests one MIG partition of the named profile instead of
+	// whole GPUs; the per-GPU amounts are then given by the partition.
+	Profile string
+}
+
+func (r GPURequirement) Clone() GPURequirement {
+	cloned := r
+	if r.Models != nil {
+		cloned.Models = append([]string(nil), r.Models...)
+	}
+	return cloned
+}
+
+// Accepts reports whether the device itself meets the requirement's
+// constraints, ignoring how much of it is already allocated.
+func (r GPURequirement) Accepts(g *GPU) bool {
+	return r.Explain(g) == ""
+}
+
+// Explain returns why the device does not meet the requirement's
+// constraints, or "" if it does.
+func (r GPURequirement) Explain(g *GPU) string {
+	if g.TFLOPS < r.MinTFLOPS {
+		return fmt.Sprintf("tflops >= %g", r.MinTFLOPS)
+	}
+	if len(r.Models) > 0 && !slices.Contains(r.Models, g.Model) {
+		return fmt.Sprintf("model in (%s)", strings.Join(r.Models, ", "))
+	}
+	if r.Profile != "" && g.MIG == nil {
+		return "mig"
+	}
+	if r.Profile != "" {
+		if _, ok := g.MIG.Profile(r.Profile); !ok {
+			return fmt.Sprintf("MIG profile %s", r.Profile)
+		}
+	}
+	if r.Profile == "" && g.MIG != nil {
+		return "!mig"
+	}
+	return r.Constraint.Explain(g)
+}
+
+// GPUBinding ties a container to one physical GPU, or to one MIG partition
+// of it when Partition is set.
+type GPUBinding struct {
+	HostID    string
+	GPUID     string
+	Partition string
+}
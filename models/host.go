@@ -1,11 +1,55 @@
 package models
 
+import (
+	"fmt"
+	"sort"
+)
+
 type Host struct {
 	ID         string
 	Containers []*Container
 	GPUs       []*GPU
 	CPUCores   int
 	Memory     int // in MB
+	Location   Location
+
+	// DiskBandwidth and NICBandwidth are the host's I/O capacities in MB/s;
+	// zero leaves that kind of I/O unmodelled.
+	DiskBandwidth int
+	NICBandwidth  int
+
+	// Topology describes how the GPUs are interconnected; nil when all GPU
+	// sets are treated as equally well connected.
+	Topology *Topology
+
+	// Power is whether the host is running. While it is on, its platform
+	// draws BasePower watts, its CPUs from CPUIdlePower to CPUMaxPower watts
+	// with their allocation, and its memory MemoryPower watts per GB
+	// installed, on top of its GPUs.
+	Power        PowerState
+	BasePower    int
+	CPUIdlePower int
+	CPUMaxPower  int
+	MemoryPower  float64
+	// Budgets are the power budgets the host's draw counts against. The
+	// broker sets them for a run; clones have none.
+	Budgets []*PowerBudget
+
+	// Capacity currently handed out to containers.
+	AllocatedCPU    int // in millicores
+	AllocatedMemory int // in MB
+
+	// shares records the CPU and memory each placed container holds on this
+	// host. A container spread over several hosts holds part of its request
+	// on each of them.
+	shares map[string]resourceShare
+}
+
+type resourceShare struct {
+	cpu     int // in millicores
+	memory  int // in MB
+	disk    int // I/O demand in MB/s
+	network int // I/O demand in MB/s
 }
 
 func NewHost(id string, cpuCores, memory int) *Host {
@@ -15,16 +59,28 @@ func NewHost(id string, cpuCores, memory int) *Host {
 		GPUs:       []*GPU{},
 		CPUCores:   cpuCores,
 		Memory:     memory,
+		shares:     map[string]resourceShare{},
 	}
 }
 
 func (h *Host) Clone() *Host {
 	clonedHost := &Host{
-		ID:         h.ID,
-		CPUCores:   h.CPUCores,
-		Memory:     h.Memory,
-		GPUs:       make([]*GPU, len(h.GPUs)),
-		Containers: make([]*Container, len(h.Containers)),
+		ID:              h.ID,
+		CPUCores:        h.CPUCores,
+		Memory:          h.Memory,
+		Location:        h.Location,
+		DiskBandwidth:   h.DiskBandwidth,
+		NICBandwidth:    h.NICBandwidth,
+		Power:           h.Power,
+		BasePower:       h.BasePower,
+		CPUIdlePower:    h.CPUIdlePower,
+		CPUMaxPower:     h.CPUMaxPower,
+		MemoryPower:     h.MemoryPower,
+		AllocatedCPU:    h.AllocatedCPU,
+		AllocatedMemory: h.AllocatedMemory,
+		GPUs:            make([]*GPU, len(h.GPUs)),
+		Containers:      make([]*Container, len(h.Containers)),
+		shares:          make(map[string]resourceShare, len(h.shares)),
 	}
 
 	// Deep copy GPUs
@@ -37,40 +93,299 @@ func (h *Host) Clone() *Host {
 		clonedHost.Containers[i] = container.Clone()
 	}
 
-	return clonedHost
-}
+	for containerID, share := range h.shares {
+		clonedHost.shares[containerID] = share
+	}
 
-func (h *Host) AddContainer(c *Container) {
-	h.Containers = append(h.Containers, c)
+	if h.Topology != nil {
+		clonedHost.Topology = h.Topology.Clone()
+	}
+
+	return clonedHost
 }
 
 func (h *Host) AddGPU(g *GPU) {
 	h.GPUs = append(h.GPUs, g)
 }
 
-func (h *Host) RemoveContainer(containerID string) {
+// FreeCPU returns the unallocated CPU in millicores.
+func (h *Host) FreeCPU() int {
+	return h.CPUCores*1000 - h.AllocatedCPU
+}
+
+// FreeMemory returns the unallocated memory in MB.
+func (h *Host) FreeMemory() int {
+	return h.Memory - h.AllocatedMemory
+}
+
+// CanFit reports whether the host is powered on, its free capacity covers
+// the container's CPU and memory requests, enough of its GPUs can take the
+// GPU request and its power budgets admit the container.
+func (h *Host) CanFit(c *Container) bool {
+	return h.Power == PoweredOn && h.CouldFit(c) && h.WithinPowerBudgets(c)
+}
+
+// CouldFit reports whether the container would fit on the host were it
+// powered on.
+func (h *Host) CouldFit(c *Container) bool {
+	if h.FreeCPU() < c.CPURequest || h.FreeMemory() < c.MemoryRequest {
+		return false
+	}
+	return h.findGPUs(c, c.GPURequest.Count) != nil
+}
+
+// findGPUs picks count GPUs that would serve the container's GPU request, or
+// returns nil if the host does not have that many. For MIG partitions it
+// prefers GPUs with an idle partition of the profile, then the GPUs with the
+// fewest free slices, so that larger profiles keep room elsewhere. Requests
+// for several GPUs get the best-connected set the host's topology allows.
+func (h *Host) findGPUs(c *Container, count int) []*GPU {
+	if count == 0 {
+		return []*GPU{}
+	}
+	var gpus []*GPU
+	for _, gpu := range h.GPUs {
+		if gpu.Fits(c.GPURequest) {
+			gpus = append(gpus, gpu)
+		}
+	}
+	if len(gpus) < count {
+		return nil
+	}
+	if profile := c.GPURequest.Profile; profile != "" {
+		sort.SliceStable(gpus, func(i, j int) bool {
+			ri, rj := gpus[i].MIG.NeedsReconfiguration(profile), gpus[j].MIG.NeedsReconfiguration(profile)
+			if ri != rj {
+				return !ri
+			}
+			return gpus[i].MIG.FreeSlices() < gpus[j].MIG.FreeSlices()
+		})
+	}
+	if h.Topology != nil && count > 1 {
+		return h.Topology.SelectGPUs(gpus, count)
+	}
+	return gpus[:count]
+}
+
+// TopologyOf returns the slowest link between the container's GPUs on this
+// host and the best slowest link the host could offer a set of that size.
+// ok is false when the host has no topology or the container holds fewer
+// than two GPUs here.
+func (h *Host) TopologyOf(c *Container) (got, best LinkType, ok bool) {
+	gpus := h.GPUsOf(c)
+	if h.Topology == nil || len(gpus) < 2 {
+		return 0, 0, false
+	}
+	ids := make([]string, len(gpus))
+	for i, gpu := range gpus {
+		ids[i] = gpu.ID
+	}
+	return h.Topology.WorstLink(ids), h.Topology.OptimalWorstLink(h.GPUs, len(gpus)), true
+}
+
+// FittingGPUs counts the GPUs on the host that could take one GPU of the
+// container's request, as many as its power budgets admit; none while the
+// host is not powered on.
+func (h *Host) FittingGPUs(c *Container) int {
+	if h.Power != PoweredOn {
+		return 0
+	}
+	var gpus []*GPU
+	for _, gpu := range h.GPUs {
+		if gpu.Fits(c.GPURequest) {
+			gpus = append(gpus, gpu)
+		}
+	}
+	count := len(gpus)
+	for count > 0 && h.exceededBudget(c, gpus[:count], c.CPURequest) != nil {
+		count--
+	}
+	return count
+}
+
+// Allocate places the whole container on the host, reserving its CPU, memory
+// and GPU request and binding it to the chosen GPUs. It refuses to
+// overcommit any resource.
+func (h *Host) Allocate(c *Container) error {
+	if c.HostID != "" {
+		return fmt.Errorf("container %s is already placed on host %s", c.ID, c.HostID)
+	}
+	c.SetupDelay = 0
+	return h.allocateShare(c, c.GPURequest.Count, c.CPURequest, c.MemoryRequest)
+}
+
+// allocateShare reserves part of a container's request on this host: gpuCount
+// of its GPUs together with the given CPU and memory.
+func (h *Host) allocateShare(c *Container, gpuCount, cpu, memory int) error {
+	if _, ok := h.shares[c.ID]; ok {
+		return fmt.Errorf("container %s already holds resources on host %s", c.ID, h.ID)
+	}
+	if h.Power != PoweredOn {
+		return fmt.Errorf("host %s is powered %s", h.ID, h.Power)
+	}
+	if h.FreeCPU() < cpu {
+		return fmt.Errorf("host %s has %d free millicores, container %s requests %d", h.ID, h.FreeCPU(), c.ID, cpu)
+	}
+	if h.FreeMemory() < memory {
+		return fmt.Errorf("host %s has %d MB free memory, container %s requests %d", h.ID, h.FreeMemory(), c.ID, memory)
+	}
+	gpus := h.findGPUs(c, gpuCount)
+	if gpus == nil {
+		return fmt.Errorf("host %s cannot satisfy the GPU request of container %s", h.ID, c.ID)
+	}
+	if budget := h.exceededBudget(c, gpus, cpu); budget != nil {
+		return fmt.Errorf("placing container %s on host %s would exceed power budget %s", c.ID, h.ID, budget.Name)
+	}
+
+	bindings, setupDelay := len(c.GPUBindings), c.SetupDelay
+	for i, gpu := range gpus {
+		binding := GPUBinding{HostID: h.ID, GPUID: gpu.ID}
+		var err error
+		if c.GPURequest.Profile != "" {
+			var partition *MIGPartition
+			var reconfigured bool
+			partition, reconfigured, err = gpu.AllocatePartition(c.GPURequest, c.ID)
+			if err == nil {
+				binding.Partition = partition.ID()
+				if reconfigured {
+					c.SetupDelay = max(c.SetupDelay, gpu.MIG.ReconfigurationDelay)
+				}
+			}
+		} else {
+			err = gpu.Allocate(c.GPURequest)
+		}
+		if err != nil {
+			// Give back the GPUs already taken so a failed placement
+			// leaves neither the host nor the container half-bound.
+			for _, taken := range gpus[:i] {
+				if c.GPURequest.Profile != "" {
+					taken.ReleasePartition(c.ID)
+				} else {
+					taken.Release(c.GPURequest)
+				}
+			}
+			c.GPUBindings = c.GPUBindings[:bindings]
+			if len(c.GPUBindings) == 0 {
+				c.GPUBindings = nil
+			}
+			c.SetupDelay = setupDelay
+			return err
+		}
+		c.GPUBindings = append(c.GPUBindings, binding)
+	}
+	// A container spread over hosts does its I/O on each of them in
+	// proportion to the GPUs it holds there.
+	disk, network := c.DiskIO, c.NetworkIO
+	if total := c.GPURequest.Count; gpuCount < total {
+		disk = ceilDiv(disk*gpuCount, total)
+		network = ceilDiv(network*gpuCount, total)
+	}
+	h.AllocatedCPU += cpu
+	h.AllocatedMemory += memory
+	h.shares[c.ID] = resourceShare{cpu: cpu, memory: memory, disk: disk, network: network}
+	h.Containers = append(h.Containers, c)
+	if c.HostID == "" {
+		c.HostID = h.ID
+	}
+	return nil
+}
+
+// Release removes the container from the host and frees everything it held
+// here, including its bindings to this host's GPUs.
+func (h *Host) Release(containerID string) {
 	for i, c := range h.Containers {
-		if c.ID == containerID {
-			h.Containers = append(h.Containers[:i], h.Containers[i+1:]...)
-			break
+		if c.ID != containerID {
+			continue
+		}
+		share := h.shares[containerID]
+		h.AllocatedCPU -= share.cpu
+		h.AllocatedMemory -= share.memory
+		delete(h.shares, containerID)
+		for _, gpu := range h.GPUsOf(c) {
+			if c.GPURequest.Profile != "" {
+				gpu.ReleasePartition(c.ID)
+			} else {
+				gpu.Release(c.GPURequest)
+			}
 		}
+
+		remaining := c.GPUBindings[:0]
+		for _, binding := range c.GPUBindings {
+			if binding.HostID != h.ID {
+				remaining = append(remaining, binding)
+			}
+		}
+		c.GPUBindings = remaining
+		if c.HostID == h.ID {
+			c.HostID = ""
+			if len(remaining) > 0 {
+				c.HostID = remaining[0].HostID
+			}
+		}
+		if len(c.GPUBindings) == 0 {
+			c.GPUBindings = nil
+		}
+
+		h.Containers = append(h.Containers[:i], h.Containers[i+1:]...)
+		return
 	}
 }
 
-func (h *Host) GetCPUUsage() float64 {
-	var totalUsage float64
-	for _, container := range h.Containers {
-		totalUsage += float64(container.CPURequest) / 1000 // Convert millicores to cores
+// GPUsOf returns the GPUs on this host that the container is bound to.
+func (h *Host) GPUsOf(c *Container) []*GPU {
+	var gpus []*GPU
+	for _, binding := range c.GPUBindings {
+		if binding.HostID != h.ID {
+			continue
+		}
+		if gpu := h.GPU(binding.GPUID); gpu != nil {
+			gpus = append(gpus, gpu)
+		}
 	}
-	return (totalUsage / float64(h.CPUCores)) * 100 // Return as percentage
+	return gpus
 }
 
-func (h *Host) GetMemoryUsage() float64 {
-	var totalUsage int
-	for _, container := range h.Containers {
-		totalUsage += container.MemoryRequest
+// GPU returns the GPU with the given ID, or nil if the host has none.
+func (h *Host) GPU(id string) *GPU {
+	for _, gpu := range h.GPUs {
+		if gpu.ID == id {
+			return gpu
+		}
+	}
+	return nil
+}
+
+// Resize changes a placed container's CPU and memory requests. Growth is
+// capped at the host's free capacity so the host is never overcommitted,
+// and CPU growth at what the host's power budgets would admit for a
+// placement. Containers spread over several hosts are left unchanged.
+func (h *Host) Resize(c *Container, cpuRequest, memoryRequest int) {
+	if c.IsDistributed() {
+		return
+	}
+	cpuRequest = min(cpuRequest, c.CPURequest+h.FreeCPU())
+	if cpuRequest > c.CPURequest {
+		for _, budget := range h.Budgets {
+			cpuRequest = min(cpuRequest, c.CPURequest+budget.cpuHeadroom(h))
+		}
 	}
-	return (float64(totalUsage) / float64(h.Memory)) * 100 // Return as percentage
+	memoryRequest = min(memoryRequest, c.MemoryRequest+h.FreeMemory())
+
+	h.AllocatedCPU += cpuRequest - c.CPURequest
+	h.AllocatedMemory += memoryRequest - c.MemoryRequest
+	c.CPURequest = cpuRequest
+	c.MemoryRequest = memoryRequest
+	share := h.shares[c.ID]
+	share.cpu, share.memory = cpuRequest, memoryRequest
+	h.shares[c.ID] = share
+}
+
+func (h *Host) GetCPUUsage() float64 {
+	return (float64(h.AllocatedCPU) / 1000 / float64(h.CPUCores)) * 100 // Return as percentage
+}
+
+func (h *Host) GetMemoryUsage() float64 {
+	return (float64(h.AllocatedMemory) / float64(h.Memory)) * 100 // Return as percentage
 }
 
 func (h *Host) GetGPUUsage() float64 {
@@ -79,20 +394,53 @@ func (h *Host) GetGPUUsage() float64 {
 	}
 
 	totalGPUCores := 0
+	usedGPUCores := 0
 	for _, gpu := range h.GPUs {
 		totalGPUCores += gpu.CUDACores
-	}
-
-	usedGPUCores := 0
-	for _, container := range h.Containers {
-		usedGPUCores += container.GPURequest.CUDACores
+		usedGPUCores += min(gpu.AllocatedCUDACores, gpu.CUDACores) // shared GPUs may be oversubscribed
 	}
 
 	return (float64(usedGPUCores) / float64(totalGPUCores)) * 100
 }
 
-func (h *Host) GetIOUsage() float64 {
-	// Implementing I/O usage might require additional tracking mechanisms
-	// This is a placeholder implementation
-	return 50.0 // Return a constant value for now
+// Slowdown returns the factor by which contention on this host slows the
+// container down: the worse of its I/O slowdown and its GPU sharing slowdown
+// compounded by throttled GPU clocks.
+func (h *Host) Slowdown(c *Container) float64 {
+	return max(h.GPUSlowdown(c)*h.ClockSlowdown(c), h.IOSlowdown(c))
+}
+
+// GPUSlowdown returns the factor by which sharing GPUs on this host slows
+// the container down: the worst slowdown over the shared GPUs it is bound to
+// here, or 1 when it shares none.
+func (h *Host) GPUSlowdown(c *Container) float64 {
+	slowdown := 1.0
+	for _, gpu := range h.GPUsOf(c) {
+		if gpu.Sharing == nil {
+			continue
+		}
+		var others []GPURequirement
+		for _, other := range h.Containers {
+			if other.ID != c.ID && other.boundTo(h.ID, gpu.ID) {
+				others = append(others, other.GPURequest)
+			}
+		}
+		slowdown = max(slowdown, gpu.Sharing.Slowdown(gpu, c.GPURequest, others))
+	}
+	return slowdown
+}
+
+// MIGSlices counts the free compute slices on the host's MIG-enabled GPUs
+// and how many of them are stranded: free, but not usable by the largest
+// profile that still fits on their GPU.
+func (h *Host) MIGSlices() (free, stranded int) {
+	for _, gpu := range h.GPUs {
+		if gpu.MIG == nil {
+			continue
+		}
+		gpuFree := gpu.MIG.FreeSlices()
+		free += gpuFree
+		stranded += gpuFree - gpu.MIG.LargestFree()
+	}
+	return free, stranded
 }
@@ -0,0 +1,78 @@
+package models
+
+import "testing"
+
+func TestNewHostAcceptsPlacements(t *testing.T) {
+	host := NewHost("host-1", 8, 16384)
+	host.AddGPU(NewGPU("gpu-1", 8192, 256, 24576, 900, 30, 300))
+	c := NewContainer("c-1", 2000, 4096, GPURequirement{Count: 1, CUDACores: 1024, VRAM: 4096}, 1)
+
+	if err := host.Allocate(c); err != nil {
+		t.Fatalf("Allocate on a new host: %v", err)
+	}
+	if c.HostID != "host-1" || len(c.GPUBindings) != 1 {
+		t.Fatalf("container not bound: host %q, bindings %v", c.HostID, c.GPUBindings)
+	}
+
+	host.Release(c.ID)
+	if host.AllocatedCPU != 0 || host.AllocatedMemory != 0 || host.GPUs[0].AllocatedCUDACores != 0 {
+		t.Fatalf("release left allocations behind: cpu %d, memory %d, cores %d",
+			host.AllocatedCPU, host.AllocatedMemory, host.GPUs[0].AllocatedCUDACores)
+	}
+}
+
+func TestAllocateReleasesGPUsOnPartialFailure(t *testing.T) {
+	host := NewHost("host-1", 8, 16384)
+	gpu := NewGPU("gpu-1", 8192, 256, 24576, 900, 30, 300)
+	// The same device listed twice passes findGPUs for both slots, but only
+	// one copy of the request fits, so the second Allocate fails.
+	host.AddGPU(gpu)
+	host.AddGPU(gpu)
+	c := NewContainer("c-1", 2000, 4096, GPURequirement{Count: 2, CUDACores: 6000, VRAM: 4096}, 1)
+
+	if err := host.Allocate(c); err == nil {
+		t.Fatal("Allocate succeeded, want the second GPU to fail")
+	}
+	if gpu.AllocatedCUDACores != 0 || gpu.AllocatedVRAM != 0 {
+		t.Errorf("GPU still holds %d cores and %d MB after the failed placement", gpu.AllocatedCUDACores, gpu.AllocatedVRAM)
+	}
+	if c.GPUBindings != nil || c.HostID != "" {
+		t.Errorf("container left bound: host %q, bindings %v", c.HostID, c.GPUBindings)
+	}
+	if host.AllocatedCPU != 0 || host.AllocatedMemory != 0 || len(host.Containers) != 0 {
+		t.Errorf("host left allocated: cpu %d, memory %d, containers %d", host.AllocatedCPU, host.AllocatedMemory, len(host.Containers))
+	}
+
+	// The GPU is free again, so a container that fits is placed.
+	c2 := NewContainer("c-2", 2000, 4096, GPURequirement{Count: 1, CUDACores: 6000, VRAM: 4096}, 1)
+	if err := host.Allocate(c2); err != nil {
+		t.Fatalf("Allocate after rollback: %v", err)
+	}
+}
+
+func TestResizeStaysWithinPowerBudgets(t *testing.T) {
+	host := NewHost("host-1", 8, 16384)
+	host.BasePower, host.CPUIdlePower, host.CPUMaxPower = 100, 50, 250
+	c := NewContainer("c-1", 2000, 4096, GPURequirement{}, 1)
+	if err := host.Allocate(c); err != nil {
+		t.Fatal(err)
+	}
+	// 200 W over 8000 millicores is 0.025 W per millicore, so 50 W of
+	// headroom admits 2000 more.
+	budget := &PowerBudget{Name: "rack", Limit: host.GetPowerDraw() + 50, Hosts: []*Host{host}}
+	host.Budgets = []*PowerBudget{budget}
+
+	host.Resize(c, 6000, 4096)
+	if c.CPURequest != 4000 || host.AllocatedCPU != 4000 {
+		t.Errorf("CPU request %d, allocated %d, want growth capped at 4000", c.CPURequest, host.AllocatedCPU)
+	}
+	if draw := budget.Draw(); draw > budget.Limit+1e-9 {
+		t.Errorf("draw %.2f W over the %.2f W limit", draw, budget.Limit)
+	}
+
+	// Shrinking is never held back, even with the budget used up.
+	host.Resize(c, 1000, 4096)
+	if c.CPURequest != 1000 {
+		t.Errorf("CPU request %d after shrinking, want 1000", c.CPURequest)
+	}
+}
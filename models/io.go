@@ -0,0 +1,75 @@
+package models
+
+import "sort"
+
+// IODemand returns the disk and network throughput the host's containers
+// need to run at full speed, in MB/s.
+func (h *Host) IODemand() (disk, network int) {
+	for _, share := range h.shares {
+		disk += share.disk
+		network += share.network
+	}
+	return disk, network
+}
+
+// GetIOUsage returns the utilization of the host's busier I/O path, disk or
+// NIC, as a percentage. Demand beyond capacity is throttled, so it never
+// exceeds 100.
+func (h *Host) GetIOUsage() float64 {
+	disk, network := h.IODemand()
+	return max(ioUtilization(disk, h.DiskBandwidth), ioUtilization(network, h.NICBandwidth))
+}
+
+func ioUtilization(demand, capacity int) float64 {
+	if capacity == 0 {
+		return 0
+	}
+	return min(float64(demand)/float64(capacity), 1) * 100
+}
+
+// IOSlowdown returns the factor by which I/O contention on this host slows
+// the container down. An oversubscribed disk or NIC is shared max-min
+// fairly: containers needing less than an equal share get all they need and
+// the rest split what is left. The container is slowed by the ratio of its
+// demand to the throughput it gets, on whichever path throttles it most.
+func (h *Host) IOSlowdown(c *Container) float64 {
+	share, ok := h.shares[c.ID]
+	if !ok {
+		return 1
+	}
+	var disks, networks []int
+	for _, other := range h.shares {
+		disks = append(disks, other.disk)
+		networks = append(networks, other.network)
+	}
+	return max(throttle(share.disk, disks, h.DiskBandwidth), throttle(share.network, networks, h.NICBandwidth))
+}
+
+// throttle returns how much longer a demand of own takes when all demands
+// share the capacity max-min fairly.
+func throttle(own int, demands []int, capacity int) float64 {
+	if own == 0 || capacity == 0 {
+		return 1
+	}
+	level := fairLevel(demands, capacity)
+	if float64(own) <= level {
+		return 1
+	}
+	return float64(own) / level
+}
+
+// fairLevel returns the most any one demand receives under max-min fair
+// sharing of the capacity.
+func fairLevel(demands []int, capacity int) float64 {
+	sorted := append([]int(nil), demands...)
+	sort.Ints(sorted)
+	remaining := float64(capacity)
+	for i, demand := range sorted {
+		fair := remaining / float64(len(sorted)-i)
+		if float64(demand) > fair {
+			return fair
+		}
+		remaining -= float64(demand)
+	}
+	return float64(capacity) // everyone is satisfied
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Job groups containers that belong together, such as the workers of a
+// distributed training run. The containers of a gang job are placed all at
+// once or not at all.
+type Job struct {
+	ID         string
+	Containers []*Container
+	Gang       bool
+	// GangTimeout is how long a gang may wait for capacity before it is
+	// failed. Zero means it waits indefinitely.
+	GangTimeout time.Duration
+
+	// Lifecycle, in simulated time since the start of the run. State uses
+	// the container states: Pending, Running, Completed or Failed.
+	SubmitTime time.Duration
+	StartTime  time.Duration
+	State      ContainerState
+}
+
+func NewJob(id string, containers []*Container, gang bool, gangTimeout time.Duration) *Job {
+	job := &Job{
+		ID:          id,
+		Containers:  containers,
+		Gang:        gang,
+		GangTimeout: gangTimeout,
+		State:       Pending,
+	}
+	for _, c := range containers {
+		c.JobID = id
+		if c.SubmitTime > job.SubmitTime {
+			job.SubmitTime = c.SubmitTime
+		}
+	}
+	return job
+}
+
+// Clone copies the job, taking its members from containers (keyed by ID) so
+// that the clone refers to an already cloned set of containers.
+func (j *Job) Clone(containers map[string]*Container) *Job {
+	cloned := &Job{
+		ID:          j.ID,
+		Containers:  make([]*Container, len(j.Containers)),
+		Gang:        j.Gang,
+		GangTimeout: j.GangTimeout,
+		SubmitTime:  j.SubmitTime,
+		StartTime:   j.StartTime,
+		State:       j.State,
+	}
+	for i, c := range j.Containers {
+		if shared, ok := containers[c.ID]; ok {
+			cloned.Containers[i] = shared
+		} else {
+			cloned.Containers[i] = c.Clone()
+		}
+	}
+	return cloned
+}
+
+// WaitTime is how long the job waited between submission and start. A job
+// that has not started has been waiting since submission, or not at all if
+// it has not been submitted yet.
+func (j *Job) WaitTime(now time.Duration) time.Duration {
+	if j.State == Pending {
+		return max(now-j.SubmitTime, 0)
+	}
+	return j.StartTime - j.SubmitTime
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobWaitTime(t *testing.T) {
+	c := NewContainer("c-1", 1000, 1024, GPURequirement{}, 1)
+	c.SubmitTime = time.Hour
+	job := NewJob("job-1", []*Container{c}, false, 0)
+
+	if got := job.WaitTime(30 * time.Minute); got != 0 {
+		t.Errorf("WaitTime before submission = %v, want 0", got)
+	}
+	if got := job.WaitTime(90 * time.Minute); got != 30*time.Minute {
+		t.Errorf("WaitTime while pending = %v, want 30m", got)
+	}
+
+	job.State = Running
+	job.StartTime = 2 * time.Hour
+	if got := job.WaitTime(5 * time.Hour); got != time.Hour {
+		t.Errorf("WaitTime once started = %v, want 1h", got)
+	}
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Location places a host in the datacenter hierarchy.
+type Location struct {
+	Region string
+	Zone   string
+	Rack   string
+}
+
+// Level is a level of the datacenter hierarchy, from the widest failure
+// domain to a single host.
+type Level int
+
+const (
+	RegionLevel Level = iota
+	ZoneLevel
+	RackLevel
+	HostLevel
+)
+
+// Levels lists every level from the widest to the narrowest.
+var Levels = []Level{RegionLevel, ZoneLevel, RackLevel, HostLevel}
+
+func (l Level) String() string {
+	switch l {
+	case RegionLevel:
+		return "region"
+	case ZoneLevel:
+		return "zone"
+	case RackLevel:
+		return "rack"
+	case HostLevel:
+		return "host"
+	}
+	return "unknown"
+}
+
+// ParseLevel parses a level name as written in scenario files.
+func ParseLevel(name string) (Level, error) {
+	for _, level := range Levels {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level %q, want region, zone, rack or host", name)
+}
+
+// Domain names the failure domain the host belongs to at the given level.
+// Names include the enclosing domains, so racks of different zones never
+// collide.
+func (h *Host) Domain(level Level) string {
+	parts := []string{h.Location.Region, h.Location.Zone, h.Location.Rack, h.ID}
+	domain := parts[0]
+	for _, part := range parts[1 : level+1] {
+		domain += "/" + part
+	}
+	return domain
+}
+
+// Proximity returns the narrowest level at which the hosts share a failure
+// domain: HostLevel for the same host, RegionLevel for hosts in the same
+// region but different zones, and -1 for hosts in different regions.
+func (h *Host) Proximity(other *Host) Level {
+	for level := HostLevel; level >= RegionLevel; level-- {
+		if h.Domain(level) == other.Domain(level) {
+			return level
+		}
+	}
+	return -1
+}
+
+// PlacementPolicy says how the containers of a placement group are laid out
+// over the failure domains of a level.
+type PlacementPolicy int
+
+const (
+	// Spread keeps the group's containers in different domains.
+	Spread PlacementPolicy = iota
+	// Pack keeps the group's containers in the same domain.
+	Pack
+)
+
+func (p PlacementPolicy) String() string {
+	if p == Pack {
+		return "pack"
+	}
+	return "spread"
+}
+
+// ParsePlacementPolicy parses a policy name as written in scenario files.
+func ParsePlacementPolicy(name string) (PlacementPolicy, error) {
+	switch name {
+	case "spread":
+		return Spread, nil
+	case "pack":
+		return Pack, nil
+	}
+	return 0, fmt.Errorf("unknown placement policy %q, want spread or pack", name)
+}
+
+// PlacementRule constrains a container's host relative to the other
+// containers of its placement group. A required rule excludes hosts that
+// break it; otherwise it only orders the hosts by preference.
+type PlacementRule struct {
+	Level    Level
+	Policy   PlacementPolicy
+	Required bool
+}
+
+func (r PlacementRule) String() string {
+	s := fmt.Sprintf("%s over %ss", r.Policy, r.Level)
+	if r.Required {
+		s += " (required)"
+	}
+	return s
+}
+
+// Spreads reports whether the container has a rule to spread its placement
+// group over failure domains.
+func (c *Container) Spreads() bool {
+	for _, rule := range c.Placement {
+		if rule.Policy == Spread {
+			return c.Group != ""
+		}
+	}
+	return false
+}
+
+// groupCounts counts, for each level and failure domain, the other
+// containers of c's placement group that hold resources there.
+func groupCounts(c *Container, hosts []*Host) map[Level]map[string]int {
+	counts := map[Level]map[string]int{}
+	for _, level := range Levels {
+		counts[level] = map[string]int{}
+	}
+	if c.Group == "" {
+		return counts
+	}
+	seen := map[Level]map[string]bool{}
+	for _, level := range Levels {
+		seen[level] = map[string]bool{}
+	}
+	for _, host := range hosts {
+		for _, other := range host.Containers {
+			if other.Group != c.Group || other.ID == c.ID {
+				continue
+			}
+			for _, level := range Levels {
+				// A container spread over hosts counts once per domain.
+				key := other.ID + "@" + host.Domain(level)
+				if !seen[level][key] {
+					seen[level][key] = true
+					counts[level][host.Domain(level)]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// RankHosts applies the container's placement rules to the hosts: it drops
+// the hosts that break a required rule and orders the rest so that hosts
+// honoring the rules best come first, in the order the rules are listed.
+// Hosts that rank equally keep their relative order, so each strategy's own
+// preference still decides between them. Containers without placement rules
+// get the hosts back unchanged.
+func RankHosts(c *Container, hosts []*Host) []*Host {
+	if len(c.Placement) == 0 {
+		return hosts
+	}
+	counts := groupCounts(c, hosts)
+	members := 0
+	for _, count := range counts[RegionLevel] {
+		members += count
+	}
+
+	var ranked []*Host
+	for _, host := range hosts {
+		if placementViolation(c, host, counts, members) == "" {
+			ranked = append(ranked, host)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		for _, rule := range c.Placement {
+			ci := counts[rule.Level][ranked[i].Domain(rule.Level)]
+			cj := counts[rule.Level][ranked[j].Domain(rule.Level)]
+			if ci == cj {
+				continue
+			}
+			if rule.Policy == Spread {
+				return ci < cj
+			}
+			return ci > cj
+		}
+		return false
+	})
+	return ranked
+}
+
+// placementViolation returns the required rule the host would break, or "".
+func placementViolation(c *Container, host *Host, counts map[Level]map[string]int, members int) string {
+	for _, rule := range c.Placement {
+		if !rule.Required {
+			continue
+		}
+		count := counts[rule.Level][host.Domain(rule.Level)]
+		if rule.Policy == Spread && count > 0 || rule.Policy == Pack && members > 0 && count == 0 {
+			return rule.String()
+		}
+	}
+	return ""
+}
+
+// PlacementBlocked describes the required placement rule that rules out
+// every host for the container, or returns "" if some host is allowed.
+func PlacementBlocked(c *Container, hosts []*Host) string {
+	if len(c.Placement) == 0 || len(hosts) == 0 {
+		return ""
+	}
+	counts := groupCounts(c, hosts)
+	members := 0
+	for _, count := range counts[RegionLevel] {
+		members += count
+	}
+	var rule string
+	for _, host := range hosts {
+		rule = placementViolation(c, host, counts, members)
+		if rule == "" {
+			return ""
+		}
+	}
+	return rule
+}
@@ -5,6 +5,10 @@ type Metrics struct {
 	MemoryUsage float64
 	GPUUsage    float64
 	IOUsage     float64
+	PowerDraw   float64 // in watts
+	// MIGFragmentation is the percentage of free MIG slices that are
+	// stranded by the partition layout.
+	MIGFragmentation float64
 }
 
 func NewMetrics(cpuUsage, memoryUsage, gpuUsage, ioUsage float64) Metrics {
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MIGProfile is a partition shape a MIG-capable GPU can be carved into, such
+// as 1g.10gb or 3g.40gb. A partition occupies Slices consecutive compute
+// slices and may only start at one of the offsets in Starts, which gives
+// every profile a fixed geometry on the device.
+type MIGProfile struct {
+	Name   string
+	Slices int
+	Memory int // in MB
+	Starts []int
+}
+
+// MIGPartition is one configured partition on a GPU. It stays configured
+// after its container leaves so that a later request for the same profile
+// can reuse it without reconfiguring the GPU.
+type MIGPartition struct {
+	Profile     string
+	Start       int
+	Slices      int
+	ContainerID string // empty when the partition is idle
+}
+
+// ID identifies the partition on its GPU, e.g. "3g.40gb@4".
+func (p *MIGPartition) ID() string {
+	return fmt.Sprintf("%s@%d", p.Profile, p.Start)
+}
+
+func (p *MIGPartition) overlaps(start, slices int) bool {
+	return start < p.Start+p.Slices && p.Start < start+slices
+}
+
+// MIGLayout is the partitioning of a MIG-enabled GPU. Changing the layout,
+// i.e. destroying idle partitions to create one of another profile, takes
+// ReconfigurationDelay before the new partition is usable.
+type MIGLayout struct {
+	Slices               int
+	Profiles             []MIGProfile
+	ReconfigurationDelay time.Duration
+	Partitions           []*MIGPartition
+}
+
+func NewMIGLayout(slices int, profiles []MIGProfile, reconfigurationDelay time.Duration) *MIGLayout {
+	return &MIGLayout{
+		Slices:               slices,
+		Profiles:             profiles,
+		ReconfigurationDelay: reconfigurationDelay,
+	}
+}
+
+func (l *MIGLayout) Clone() *MIGLayout {
+	cloned := &MIGLayout{
+		Slices:               l.Slices,
+		Profiles:             l.Profiles, // profiles are never modified
+		ReconfigurationDelay: l.ReconfigurationDelay,
+		Partitions:           make([]*MIGPartition, len(l.Partitions)),
+	}
+	for i, p := range l.Partitions {
+		partition := *p
+		cloned.Partitions[i] = &partition
+	}
+	return cloned
+}
+
+// Profile returns the profile with the given name.
+func (l *MIGLayout) Profile(name string) (MIGProfile, bool) {
+	for _, profile := range l.Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return MIGProfile{}, false
+}
+
+// Fits reports whether a partition of the profile can be handed out, either
+// by reusing an idle one or by reconfiguring around the busy ones.
+func (l *MIGLayout) Fits(name string) bool {
+	profile, ok := l.Profile(name)
+	if !ok {
+		return false
+	}
+	if l.idle(name) != nil {
+		return true
+	}
+	_, ok = l.placement(profile)
+	return ok
+}
+
+// NeedsReconfiguration reports whether handing out a partition of the
+// profile would require changing the layout.
+func (l *MIGLayout) NeedsReconfiguration(name string) bool {
+	return l.idle(name) == nil
+}
+
+// Assign hands a partition of the profile to the container, reusing an idle
+// partition when there is one. reconfigured reports whether the layout had
+// to change to make room.
+func (l *MIGLayout) Assign(name, containerID string) (partition *MIGPartition, reconfigured bool, err error) {
+	profile, ok := l.Profile(name)
+	if !ok {
+		return nil, false, fmt.Errorf("unknown MIG profile %q", name)
+	}
+	if partition := l.idle(name); partition != nil {
+		partition.ContainerID = containerID
+		return partition, false, nil
+	}
+
+	start, ok := l.placement(profile)
+	if !ok {
+		return nil, false, fmt.Errorf("no room for a %s partition", name)
+	}
+	kept := l.Partitions[:0]
+	for _, p := range l.Partitions {
+		if p.ContainerID != "" || !p.overlaps(start, profile.Slices) {
+			kept = append(kept, p)
+		}
+	}
+	partition = &MIGPartition{Profile: name, Start: start, Slices: profile.Slices, ContainerID: containerID}
+	l.Partitions = append(kept, partition)
+	return partition, true, nil
+}
+
+// Unassign frees the container's partition, leaving it configured but idle.
+func (l *MIGLayout) Unassign(containerID string) *MIGPartition {
+	for _, p := range l.Partitions {
+		if p.ContainerID == containerID {
+			p.ContainerID = ""
+			return p
+		}
+	}
+	return nil
+}
+
+// FreeSlices counts the compute slices not held by a busy partition.
+func (l *MIGLayout) FreeSlices() int {
+	free := l.Slices
+	for _, p := range l.Partitions {
+		if p.ContainerID != "" {
+			free -= p.Slices
+		}
+	}
+	return free
+}
+
+// LargestFree returns the size in slices of the largest profile that could
+// still be handed out.
+func (l *MIGLayout) LargestFree() int {
+	largest := 0
+	for _, profile := range l.Profiles {
+		if profile.Slices > largest && l.Fits(profile.Name) {
+			largest = profile.Slices
+		}
+	}
+	return largest
+}
+
+func (l *MIGLayout) idle(name string) *MIGPartition {
+	for _, p := range l.Partitions {
+		if p.Profile == name && p.ContainerID == "" {
+			return p
+		}
+	}
+	return nil
+}
+
+// placement picks a start offset for a new partition of the profile that
+// does not overlap a busy partition, preferring the offset that destroys the
+// fewest idle partitions.
+func (l *MIGLayout) placement(profile MIGProfile) (int, bool) {
+	best, bestCost := 0, -1
+	for _, start := range profile.Starts {
+		if start+profile.Slices > l.Slices {
+			continue
+		}
+		cost := 0
+		for _, p := range l.Partitions {
+			if !p.overlaps(start, profile.Slices) {
+				continue
+			}
+			if p.ContainerID != "" {
+				cost = -1
+				break
+			}
+			cost++
+		}
+		if cost >= 0 && (bestCost < 0 || cost < bestCost) {
+			best, bestCost = start, cost
+		}
+	}
+	return best, bestCost >= 0
+}
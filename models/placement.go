@@ -0,0 +1,58 @@
+package models
+
+import "fmt"
+
+// AllocateAcross places a multi-GPU container over as few of the hosts as
+// greedily possible, taking hosts in the given order. Every host receives a
+// share of the container's CPU and memory proportional to the GPUs it
+// contributes. Placement is atomic: either all GPUs are bound or nothing is
+// changed.
+func AllocateAcross(c *Container, hosts []*Host) error {
+	if c.HostID != "" {
+		return fmt.Errorf("container %s is already placed on host %s", c.ID, c.HostID)
+	}
+	count := c.GPURequest.Count
+	if count == 0 {
+		return fmt.Errorf("container %s requests no GPUs to spread", c.ID)
+	}
+
+	type share struct {
+		host              *Host
+		gpus, cpu, memory int
+	}
+	var plan []share
+	remaining := count
+	for _, host := range hosts {
+		if remaining == 0 {
+			break
+		}
+		gpus := min(host.FittingGPUs(c), remaining)
+		if gpus == 0 {
+			continue
+		}
+		cpu := ceilDiv(c.CPURequest*gpus, count)
+		memory := ceilDiv(c.MemoryRequest*gpus, count)
+		if host.FreeCPU() < cpu || host.FreeMemory() < memory {
+			continue
+		}
+		plan = append(plan, share{host: host, gpus: gpus, cpu: cpu, memory: memory})
+		remaining -= gpus
+	}
+	if remaining > 0 {
+		return fmt.Errorf("only %d of the %d GPUs requested by container %s are free", count-remaining, count, c.ID)
+	}
+
+	for i, s := range plan {
+		if err := s.host.allocateShare(c, s.gpus, s.cpu, s.memory); err != nil {
+			for _, done := range plan[:i] {
+				done.host.Release(c.ID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,156 @@
+package models
+
+// PowerState says whether a host is running. Only powered-on hosts take
+// containers.
+type PowerState int
+
+const (
+	PoweredOn PowerState = iota
+	PoweredOff
+	Booting
+)
+
+func (s PowerState) String() string {
+	switch s {
+	case PoweredOn:
+		return "on"
+	case PoweredOff:
+		return "off"
+	case Booting:
+		return "booting"
+	}
+	return "unknown"
+}
+
+// IdlePowerDraw is the power in watts the host draws while powered on with
+// nothing running, which powering it down saves.
+func (h *Host) IdlePowerDraw() float64 {
+	power := float64(h.BasePower+h.CPUIdlePower) + h.MemoryPower*float64(h.Memory)/1024
+	for _, gpu := range h.GPUs {
+		power += float64(gpu.IdlePower)
+	}
+	return power
+}
+
+// PowerBreakdown splits a host's power draw in watts, or the energy it used,
+// by component.
+type PowerBreakdown struct {
+	Platform float64
+	CPU      float64
+	Memory   float64
+	GPU      float64
+}
+
+func (p PowerBreakdown) Total() float64 {
+	return p.Platform + p.CPU + p.Memory + p.GPU
+}
+
+// Add returns the sum of both breakdowns, with q scaled by factor, e.g. a
+// duration in hours to turn power into energy.
+func (p PowerBreakdown) Add(q PowerBreakdown, factor float64) PowerBreakdown {
+	return PowerBreakdown{
+		Platform: p.Platform + q.Platform*factor,
+		CPU:      p.CPU + q.CPU*factor,
+		Memory:   p.Memory + q.Memory*factor,
+		GPU:      p.GPU + q.GPU*factor,
+	}
+}
+
+// Utilization is the share of the GPU's cores handed out to containers,
+// capped at 1 since shared GPUs may be oversubscribed.
+func (g *GPU) Utilization() float64 {
+	if g.CUDACores == 0 {
+		return 0
+	}
+	return min(float64(g.AllocatedCUDACores)/float64(g.CUDACores), 1)
+}
+
+// PowerDraw estimates the GPU's draw in watts: its idle power plus the rest
+// of its rated power scaled by its utilization and by its clock state.
+func (g *GPU) PowerDraw() float64 {
+	return g.powerDrawAt(g.Clock())
+}
+
+func (g *GPU) powerDrawAt(state DVFSState) float64 {
+	idle := float64(g.IdlePower)
+	return idle + (float64(g.PowerConsumption)-idle)*state.Power*g.Utilization()
+}
+
+// coresOf returns the cores of the GPU the container holds: its partition's
+// share on a MIG-enabled GPU, its per-GPU request otherwise.
+func (g *GPU) coresOf(c *Container) int {
+	if g.MIG == nil {
+		return c.GPURequest.CUDACores
+	}
+	for _, p := range g.MIG.Partitions {
+		if p.ContainerID == c.ID {
+			cores, _, _ := g.partitionShare(p)
+			return cores
+		}
+	}
+	return 0
+}
+
+// PowerBreakdown estimates the host's draw by component. The CPUs draw
+// between their idle and maximum power in proportion to the CPU allocated,
+// memory draws in proportion to the memory installed and each GPU by its own
+// utilization and clock. Powered-off hosts draw nothing.
+func (h *Host) PowerBreakdown() PowerBreakdown {
+	return h.powerBreakdownAt(-1)
+}
+
+// powerBreakdownAt estimates the host's draw with its GPUs at the clock state
+// with the given index, or at their current clocks if it is negative.
+func (h *Host) powerBreakdownAt(state int) PowerBreakdown {
+	if h.Power == PoweredOff {
+		return PowerBreakdown{}
+	}
+	cpu := 0.0
+	if h.CPUCores > 0 {
+		cpu = min(float64(h.AllocatedCPU)/float64(h.CPUCores*1000), 1)
+	}
+	p := PowerBreakdown{
+		Platform: float64(h.BasePower),
+		CPU:      float64(h.CPUIdlePower) + float64(h.CPUMaxPower-h.CPUIdlePower)*cpu,
+		Memory:   h.MemoryPower * float64(h.Memory) / 1024,
+	}
+	for _, gpu := range h.GPUs {
+		clock := gpu.Clock()
+		if state >= 0 {
+			clock = gpu.stateAt(state)
+		}
+		p.GPU += gpu.powerDrawAt(clock)
+	}
+	return p
+}
+
+// GetPowerDraw estimates the host's power draw in watts.
+func (h *Host) GetPowerDraw() float64 {
+	return h.PowerBreakdown().Total()
+}
+
+// ContainerPowerDraw is the part of the host's draw attributed to the
+// container: the platform and CPU power by its share of the allocated CPU,
+// the memory power by its share of the allocated memory, and each of its
+// GPUs' power by its share of that GPU's allocated cores. The draw of
+// capacity nobody holds is attributed to no container.
+func (h *Host) ContainerPowerDraw(c *Container) float64 {
+	share, ok := h.shares[c.ID]
+	if !ok || h.Power == PoweredOff {
+		return 0
+	}
+	p := h.PowerBreakdown()
+	var draw float64
+	if h.AllocatedCPU > 0 {
+		draw += (p.Platform + p.CPU) * float64(share.cpu) / float64(h.AllocatedCPU)
+	}
+	if h.AllocatedMemory > 0 {
+		draw += p.Memory * float64(share.memory) / float64(h.AllocatedMemory)
+	}
+	for _, gpu := range h.GPUsOf(c) {
+		if gpu.AllocatedCUDACores > 0 {
+			draw += gpu.PowerDraw() * float64(gpu.coresOf(c)) / float64(gpu.AllocatedCUDACores)
+		}
+	}
+	return draw
+}
@@ -0,0 +1,210 @@
+package models
+
+import "math"
+
+// DVFSState is one operating point of a GPU's clock. Performance is the
+// share of full-clock throughput the GPU delivers and Power the share of its
+// dynamic power, its rated power less its idle power, it draws at full
+// utilization.
+type DVFSState struct {
+	Clock       float64 // share of the maximum clock
+	Performance float64
+	Power       float64
+}
+
+// DefaultDVFS is the clock curve of GPUs whose model gives none: throughput
+// falls a little slower than the clock, power roughly with its square, since
+// the voltage drops with it.
+var DefaultDVFS = []DVFSState{
+	{Clock: 1.0, Performance: 1.00, Power: 1.00},
+	{Clock: 0.9, Performance: 0.93, Power: 0.80},
+	{Clock: 0.8, Performance: 0.86, Power: 0.63},
+	{Clock: 0.7, Performance: 0.78, Power: 0.49},
+	{Clock: 0.6, Performance: 0.70, Power: 0.37},
+	{Clock: 0.5, Performance: 0.61, Power: 0.27},
+}
+
+// lowestClock asks for each GPU's lowest clock state.
+const lowestClock = math.MaxInt
+
+// DVFSStates returns the GPU's clock curve, fastest state first.
+func (g *GPU) DVFSStates() []DVFSState {
+	if len(g.DVFS) == 0 {
+		return DefaultDVFS
+	}
+	return g.DVFS
+}
+
+// Clock returns the state the GPU runs at.
+func (g *GPU) Clock() DVFSState {
+	return g.stateAt(g.ClockState)
+}
+
+// Throttled reports whether a power cap holds the GPU below full clock.
+func (g *GPU) Throttled() bool {
+	return g.ClockState > 0
+}
+
+// stateAt returns the GPU's clock state with the given index, or its lowest
+// one if it has fewer states.
+func (g *GPU) stateAt(index int) DVFSState {
+	states := g.DVFSStates()
+	return states[min(index, len(states)-1)]
+}
+
+// SetClockState holds every GPU of the host at the clock state with the given
+// index, or at its lowest one.
+func (h *Host) SetClockState(index int) {
+	for _, gpu := range h.GPUs {
+		gpu.ClockState = min(index, len(gpu.DVFSStates())-1)
+	}
+}
+
+// ClockSlowdown returns the factor by which the clocks of its GPUs on this
+// host slow the container down: that of its slowest GPU here, or 1 when
+// none is throttled.
+func (h *Host) ClockSlowdown(c *Container) float64 {
+	slowdown := 1.0
+	for _, gpu := range h.GPUsOf(c) {
+		if gpu.Throttled() {
+			slowdown = max(slowdown, 1/gpu.Clock().Performance)
+		}
+	}
+	return slowdown
+}
+
+// PowerBudget caps the power drawn by a group of hosts, such as a rack or
+// the whole cluster. Placements the budget cannot take are refused. With
+// Throttle set the GPUs' clocks may be lowered to hold the draw within the
+// limit, so placements only have to fit at the lowest clocks; otherwise they
+// must fit at full clock.
+type PowerBudget struct {
+	Name     string
+	Limit    float64 // in watts
+	Hosts    []*Host
+	Throttle bool
+	// Level is the clock state index the budget holds its GPUs at or below.
+	Level int
+}
+
+// Draw returns the hosts' current power draw in watts.
+func (b *PowerBudget) Draw() float64 {
+	var draw float64
+	for _, host := range b.Hosts {
+		draw += host.GetPowerDraw()
+	}
+	return draw
+}
+
+// LevelFor returns the fastest clock state index at which the hosts' draw
+// stays within the limit, given the state each host is already held at by
+// other budgets, or the lowest state if none does.
+func (b *PowerBudget) LevelFor(held map[*Host]int) int {
+	lowest := 0
+	for _, host := range b.Hosts {
+		for _, gpu := range host.GPUs {
+			lowest = max(lowest, len(gpu.DVFSStates())-1)
+		}
+	}
+	for level := 0; level < lowest; level++ {
+		var draw float64
+		for _, host := range b.Hosts {
+			draw += host.powerBreakdownAt(max(level, held[host])).Total()
+		}
+		if draw <= b.Limit {
+			return level
+		}
+	}
+	return lowest
+}
+
+// admits reports whether the budget can take the container on the host,
+// using the given GPUs and CPU in millicores.
+func (b *PowerBudget) admits(host *Host, c *Container, gpus []*GPU, cpu int) bool {
+	state := b.admissionState()
+	return b.drawAt(state)+host.powerIncrease(c, gpus, cpu, state) <= b.Limit
+}
+
+// cpuHeadroom returns how many more millicores the budget can take on the
+// host under the same rule as placements.
+func (b *PowerBudget) cpuHeadroom(host *Host) int {
+	if host.CPUCores == 0 || host.CPUMaxPower <= host.CPUIdlePower {
+		return math.MaxInt
+	}
+	perMillicore := float64(host.CPUMaxPower-host.CPUIdlePower) / float64(host.CPUCores*1000)
+	headroom := (b.Limit - b.drawAt(b.admissionState())) / perMillicore
+	return max(int(min(headroom, float64(host.CPUCores*1000))), 0)
+}
+
+// admissionState is the clock state index placements must fit the budget
+// at: the lowest if it throttles, full clock otherwise.
+func (b *PowerBudget) admissionState() int {
+	if b.Throttle {
+		return lowestClock
+	}
+	return 0
+}
+
+// drawAt returns the hosts' power draw in watts with their GPUs at the
+// clock state with the given index.
+func (b *PowerBudget) drawAt(state int) float64 {
+	var draw float64
+	for _, host := range b.Hosts {
+		draw += host.powerBreakdownAt(state).Total()
+	}
+	return draw
+}
+
+// WithinPowerBudgets reports whether the host's power budgets admit the
+// container on the GPUs it would get. It says nothing about whether the
+// container fits.
+func (h *Host) WithinPowerBudgets(c *Container) bool {
+	if len(h.Budgets) == 0 {
+		return true
+	}
+	gpus := h.findGPUs(c, c.GPURequest.Count)
+	return gpus == nil || h.exceededBudget(c, gpus, c.CPURequest) == nil
+}
+
+// exceededBudget returns the first of the host's power budgets that cannot
+// take the container using the given GPUs and CPU, or nil if all can.
+func (h *Host) exceededBudget(c *Container, gpus []*GPU, cpu int) *PowerBudget {
+	for _, budget := range h.Budgets {
+		if !budget.admits(h, c, gpus, cpu) {
+			return budget
+		}
+	}
+	return nil
+}
+
+// powerIncrease estimates how much more power the host would draw with the
+// container placed on it using the given GPUs and CPU, with the GPUs at the
+// clock state with the given index.
+func (h *Host) powerIncrease(c *Container, gpus []*GPU, cpu, state int) float64 {
+	var increase float64
+	if h.CPUCores > 0 {
+		capacity := float64(h.CPUCores * 1000)
+		before := min(float64(h.AllocatedCPU)/capacity, 1)
+		after := min(float64(h.AllocatedCPU+cpu)/capacity, 1)
+		increase += float64(h.CPUMaxPower-h.CPUIdlePower) * (after - before)
+	}
+	for _, gpu := range gpus {
+		before := gpu.Utilization()
+		after := min(before+gpu.requestShare(c.GPURequest), 1)
+		increase += float64(gpu.PowerConsumption-gpu.IdlePower) * gpu.stateAt(state).Power * (after - before)
+	}
+	return increase
+}
+
+// requestShare is the share of the GPU's cores one GPU of the request takes.
+func (g *GPU) requestShare(request GPURequirement) float64 {
+	if g.MIG != nil && request.Profile != "" {
+		if profile, ok := g.MIG.Profile(request.Profile); ok {
+			return float64(profile.Slices) / float64(g.MIG.Slices)
+		}
+	}
+	if g.CUDACores == 0 {
+		return 0
+	}
+	return float64(request.CUDACores) / float64(g.CUDACores)
+}
@@ -0,0 +1,116 @@
+package models
+
+import "fmt"
+
+// SharingMode is how several containers share one GPU.
+type SharingMode int
+
+const (
+	// TimeSlicing runs tenants' kernels in turns, paying a context switch
+	// every time the GPU changes hands.
+	TimeSlicing SharingMode = iota
+	// MPS runs tenants' kernels concurrently through the CUDA Multi-Process
+	// Service, which mostly suffers from contention on memory bandwidth.
+	MPS
+)
+
+func (m SharingMode) String() string {
+	switch m {
+	case TimeSlicing:
+		return "time-slicing"
+	case MPS:
+		return "mps"
+	}
+	return "unknown"
+}
+
+// ParseSharingMode returns the sharing mode with the given name.
+func ParseSharingMode(name string) (SharingMode, error) {
+	switch name {
+	case "time-slicing":
+		return TimeSlicing, nil
+	case "mps":
+		return MPS, nil
+	}
+	return 0, fmt.Errorf("unknown sharing mode %q (want time-slicing or mps)", name)
+}
+
+// GPUSharing lets up to MaxTenants containers share a GPU. Their compute
+// and memory bandwidth requests are demands rather than reservations, so a
+// shared GPU may be oversubscribed; only VRAM is reserved. Tenants then
+// slow each other down according to the interference model of the mode.
+type GPUSharing struct {
+	Mode       SharingMode
+	MaxTenants int
+	// TenantOverhead is the extra slowdown each co-tenant adds: the cost of
+	// context switches under time-slicing, of MPS scheduling under MPS.
+	TenantOverhead float64
+	// BandwidthContention scales the slowdown caused by the co-tenants'
+	// memory bandwidth demand, as a fraction of the GPU's bandwidth. It only
+	// applies to MPS, where tenants' kernels run at the same time.
+	BandwidthContention float64
+}
+
+// Slowdown returns the factor by which a tenant with the given demand runs
+// slower on g than it would alone, given the other tenants' demands.
+func (s *GPUSharing) Slowdown(g *GPU, own GPURequirement, others []GPURequirement) float64 {
+	if len(others) == 0 {
+		return 1
+	}
+	if s.Mode == MPS {
+		return s.mpsSlowdown(g, own, others)
+	}
+	return s.timeSlicedSlowdown(g, own, others)
+}
+
+// timeSlicedSlowdown models tenants that take turns on the whole GPU. Each
+// needs it for the larger of its compute and bandwidth shares of the GPU;
+// once those turns add up to more than all of the GPU's time, every tenant
+// is stretched by the total. Each co-tenant adds a context switch overhead.
+func (s *GPUSharing) timeSlicedSlowdown(g *GPU, own GPURequirement, others []GPURequirement) float64 {
+	busy := g.duty(own)
+	for _, other := range others {
+		busy += g.duty(other)
+	}
+	return max(busy, 1) * (1 + s.TenantOverhead*float64(len(others)))
+}
+
+// mpsSlowdown models tenants whose kernels run concurrently and contend for
+// the GPU's SMs and memory bandwidth separately: oversubscribing either
+// stretches every tenant by the oversubscription. On top of that each
+// co-tenant adds TenantOverhead and the co-tenants' bandwidth use adds
+// BandwidthContention times their share of the GPU's bandwidth.
+func (s *GPUSharing) mpsSlowdown(g *GPU, own GPURequirement, others []GPURequirement) float64 {
+	cores := own.CUDACores
+	bandwidth := own.MemoryBandwidth
+	var othersBandwidth int
+	for _, other := range others {
+		cores += other.CUDACores
+		bandwidth += other.MemoryBandwidth
+		othersBandwidth += other.MemoryBandwidth
+	}
+
+	pressure := 1.0
+	if g.CUDACores > 0 {
+		pressure = max(pressure, float64(cores)/float64(g.CUDACores))
+	}
+	interference := s.TenantOverhead * float64(len(others))
+	if g.MemoryBandwidth > 0 {
+		pressure = max(pressure, float64(bandwidth)/float64(g.MemoryBandwidth))
+		interference += s.BandwidthContention * min(float64(othersBandwidth)/float64(g.MemoryBandwidth), 1)
+	}
+	return pressure * (1 + interference)
+}
+
+// duty is the share of g's time a request needs when it has the whole GPU
+// to itself: the larger of its compute and memory bandwidth shares.
+func (g *GPU) duty(request GPURequirement) float64 {
+	var duty float64
+	if g.CUDACores > 0 {
+		duty = float64(request.CUDACores) / float64(g.CUDACores)
+	}
+	if g.MemoryBandwidth > 0 {
+		duty = max(duty, float64(request.MemoryBandwidth)/float64(g.MemoryBandwidth))
+	}
+	return duty
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSharingSlowdown(t *testing.T) {
+	gpu := NewGPU("gpu-1", 1000, 0, 24576, 100, 30, 300)
+	// Compute-bound and bandwidth-bound tenants fit side by side under MPS
+	// but still have to take turns under time-slicing.
+	compute := GPURequirement{CUDACores: 600, MemoryBandwidth: 10}
+	memory := GPURequirement{CUDACores: 100, MemoryBandwidth: 60}
+	heavy := GPURequirement{CUDACores: 800, MemoryBandwidth: 80}
+
+	tests := []struct {
+		name    string
+		sharing GPUSharing
+		own     GPURequirement
+		others  []GPURequirement
+		want    float64
+	}{
+		{
+			name:    "alone",
+			sharing: GPUSharing{Mode: TimeSlicing, TenantOverhead: 0.1},
+			own:     heavy,
+			want:    1,
+		},
+		{
+			name:    "time-slicing within the GPU's time",
+			sharing: GPUSharing{Mode: TimeSlicing, TenantOverhead: 0.1},
+			own:     GPURequirement{CUDACores: 300, MemoryBandwidth: 10},
+			others:  []GPURequirement{memory},
+			want:    1.1,
+		},
+		{
+			name:    "time-slicing adds up each tenant's busiest resource",
+			sharing: GPUSharing{Mode: TimeSlicing},
+			own:     compute,
+			others:  []GPURequirement{memory},
+			want:    1.2,
+		},
+		{
+			name:    "time-slicing ignores bandwidth contention",
+			sharing: GPUSharing{Mode: TimeSlicing, BandwidthContention: 1},
+			own:     compute,
+			others:  []GPURequirement{memory},
+			want:    1.2,
+		},
+		{
+			name:    "mps overlaps compute and bandwidth",
+			sharing: GPUSharing{Mode: MPS},
+			own:     compute,
+			others:  []GPURequirement{memory},
+			want:    1,
+		},
+		{
+			name:    "mps oversubscribed compute",
+			sharing: GPUSharing{Mode: MPS, TenantOverhead: 0.05},
+			own:     compute,
+			others:  []GPURequirement{compute},
+			want:    1.2 * 1.05,
+		},
+		{
+			name:    "mps bandwidth contention",
+			sharing: GPUSharing{Mode: MPS, BandwidthContention: 0.5},
+			own:     compute,
+			others:  []GPURequirement{memory},
+			want:    1.3,
+		},
+		{
+			name:    "mps contention is capped at the full bandwidth",
+			sharing: GPUSharing{Mode: MPS, BandwidthContention: 0.5},
+			own:     memory,
+			others:  []GPURequirement{heavy, heavy},
+			want:    2.2 * 1.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sharing.Slowdown(gpu, tt.own, tt.others)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Slowdown = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package models
+
+// LinkType is the best interconnect path between two GPUs of a host, ordered
+// from fastest to slowest.
+type LinkType int
+
+const (
+	NVSwitchLink    LinkType = iota // both GPUs hang off the same NVSwitch fabric
+	NVLinkLink                      // direct NVLink bridge
+	PCIeSwitchLink                  // same PCIe switch
+	HostBridgeLink                  // same CPU socket / NUMA node, through the host bridge
+	CrossSocketLink                 // across the inter-socket link
+)
+
+func (l LinkType) String() string {
+	switch l {
+	case NVSwitchLink:
+		return "NVSwitch"
+	case NVLinkLink:
+		return "NVLink"
+	case PCIeSwitchLink:
+		return "PCIe switch"
+	case HostBridgeLink:
+		return "host bridge"
+	case CrossSocketLink:
+		return "cross socket"
+	}
+	return "unknown"
+}
+
+// LinkTypes lists every link type from fastest to slowest.
+var LinkTypes = []LinkType{NVSwitchLink, NVLinkLink, PCIeSwitchLink, HostBridgeLink, CrossSocketLink}
+
+// Topology describes how a host's GPUs are connected: which NUMA node and
+// PCIe switch each GPU sits under, which pairs are bridged by NVLink and
+// whether all GPUs share an NVSwitch fabric. GPUs are identified by ID.
+type Topology struct {
+	NVSwitch   bool
+	NUMANode   map[string]int
+	PCIeSwitch map[string]int
+	NVLinks    map[string][]string
+
+	// optimal caches the best achievable worst link for each GPU count.
+	optimal map[int]LinkType
+}
+
+func NewTopology() *Topology {
+	return &Topology{
+		NUMANode:   map[string]int{},
+		PCIeSwitch: map[string]int{},
+		NVLinks:    map[string][]string{},
+	}
+}
+
+func (t *Topology) Clone() *Topology {
+	cloned := NewTopology()
+	cloned.NVSwitch = t.NVSwitch
+	for id, node := range t.NUMANode {
+		cloned.NUMANode[id] = node
+	}
+	for id, sw := range t.PCIeSwitch {
+		cloned.PCIeSwitch[id] = sw
+	}
+	for id, peers := range t.NVLinks {
+		cloned.NVLinks[id] = append([]string(nil), peers...)
+	}
+	return cloned
+}
+
+// AddNVLink bridges two GPUs with NVLink.
+func (t *Topology) AddNVLink(a, b string) {
+	t.NVLinks[a] = append(t.NVLinks[a], b)
+	t.NVLinks[b] = append(t.NVLinks[b], a)
+}
+
+// Link returns the best path between two GPUs.
+func (t *Topology) Link(a, b string) LinkType {
+	switch {
+	case t.NVSwitch:
+		return NVSwitchLink
+	case t.nvlinked(a, b):
+		return NVLinkLink
+	case t.PCIeSwitch[a] == t.PCIeSwitch[b]:
+		return PCIeSwitchLink
+	case t.NUMANode[a] == t.NUMANode[b]:
+		return HostBridgeLink
+	}
+	return CrossSocketLink
+}
+
+func (t *Topology) nvlinked(a, b string) bool {
+	for _, peer := range t.NVLinks[a] {
+		if peer == b {
+			return true
+		}
+	}
+	return false
+}
+
+// WorstLink returns the slowest link between any two of the GPUs, which
+// bounds collective operations over the set. A single GPU has no links and
+// counts as NVSwitch-connected.
+func (t *Topology) WorstLink(gpuIDs []string) LinkType {
+	worst := NVSwitchLink
+	for i := range gpuIDs {
+		for j := i + 1; j < len(gpuIDs); j++ {
+			worst = max(worst, t.Link(gpuIDs[i], gpuIDs[j]))
+		}
+	}
+	return worst
+}
+
+// setScore ranks GPU sets: a better worst link first, then fewer NUMA nodes
+// spanned, then a lower total over all pairs.
+type setScore struct {
+	worst LinkType
+	numa  int
+	total int
+}
+
+func (s setScore) less(o setScore) bool {
+	if s.worst != o.worst {
+		return s.worst < o.worst
+	}
+	if s.numa != o.numa {
+		return s.numa < o.numa
+	}
+	return s.total < o.total
+}
+
+func (t *Topology) score(gpus []*GPU) setScore {
+	s := setScore{}
+	nodes := map[int]bool{}
+	for i, a := range gpus {
+		nodes[t.NUMANode[a.ID]] = true
+		for _, b := range gpus[i+1:] {
+			link := t.Link(a.ID, b.ID)
+			s.worst = max(s.worst, link)
+			s.total += int(link)
+		}
+	}
+	s.numa = len(nodes)
+	return s
+}
+
+// SelectGPUs picks count of the candidate GPUs that are well connected to
+// each other. It grows a set greedily from every candidate in turn, each
+// time adding the GPU with the best links to the set so far, and keeps the
+// best set found.
+func (t *Topology) SelectGPUs(candidates []*GPU, count int) []*GPU {
+	if count <= 1 || len(candidates) <= count {
+		return candidates[:min(count, len(candidates))]
+	}
+	var best []*GPU
+	var bestScore setScore
+	for _, seed := range candidates {
+		set := []*GPU{seed}
+		for len(set) < count {
+			var next *GPU
+			var nextScore setScore
+			for _, candidate := range candidates {
+				if containsGPU(set, candidate) {
+					continue
+				}
+				score := t.score(append(set[:len(set):len(set)], candidate))
+				if next == nil || score.less(nextScore) {
+					next, nextScore = candidate, score
+				}
+			}
+			set = append(set, next)
+		}
+		if score := t.score(set); best == nil || score.less(bestScore) {
+			best, bestScore = set, score
+		}
+	}
+	return best
+}
+
+func containsGPU(gpus []*GPU, g *GPU) bool {
+	for _, gpu := range gpus {
+		if gpu == g {
+			return true
+		}
+	}
+	return false
+}
+
+// OptimalWorstLink returns the best worst link any count of the given GPUs
+// could have, found by exhaustive search and cached per count.
+func (t *Topology) OptimalWorstLink(gpus []*GPU, count int) LinkType {
+	if best, ok := t.optimal[count]; ok {
+		return best
+	}
+	best := CrossSocketLink
+	var search func(start int, set []string)
+	search = func(start int, set []string) {
+		if len(set) == count {
+			best = min(best, t.WorstLink(set))
+			return
+		}
+		for i := start; i < len(gpus) && best > NVSwitchLink; i++ {
+			search(i+1, append(set, gpus[i].ID))
+		}
+	}
+	search(0, nil)
+	if t.optimal == nil {
+		t.optimal = map[int]LinkType{}
+	}
+	t.optimal[count] = best
+	return best
+}
@@ -1,6 +1,7 @@
 package broker
 
 import (
+	"fmt"
 	"gpu-cloudsim/models"
 	"gpu-cloudsim/pkg/scheduler"
 )
@@ -8,12 +9,22 @@ import (
 type Broker struct {
 	Hosts     []*models.Host
 	Scheduler scheduler.Scheduler
+	// Pending holds containers that could not be placed yet, in the order
+	// they were submitted.
+	Pending []*models.Container
+	// PendingJobs holds gang jobs waiting for enough capacity to place all
+	// of their containers at once.
+	PendingJobs []*models.Job
+	// Budgets cap the power drawn by racks and the cluster; see SetPowerCap.
+	Budgets []*models.PowerBudget
 }
 
 func NewBroker(scheduler scheduler.Scheduler) *Broker {
 	return &Broker{
-		Hosts:     []*models.Host{},
-		Scheduler: scheduler,
+		Hosts:       []*models.Host{},
+		Scheduler:   scheduler,
+		Pending:     []*models.Container{},
+		PendingJobs: []*models.Job{},
 	}
 }
 
@@ -21,18 +32,162 @@ func (b *Broker) AddHost(host *models.Host) {
 	b.Hosts = append(b.Hosts, host)
 }
 
-func (b *Broker) AllocateResources(containers []*models.Container) error {
-	return b.Scheduler.Schedule(containers, b.Hosts)
+// AllocateResources places the containers and returns the ones that were
+// placed. Containers that do not fit are appended to the pending queue.
+func (b *Broker) AllocateResources(containers []*models.Container) []*models.Container {
+	unplaced := b.Scheduler.Schedule(containers, b.Hosts)
+	for _, container := range unplaced {
+		container.Reason, _ = b.Explain(container)
+	}
+	b.Pending = append(b.Pending, unplaced...)
+	return placedOnly(containers, unplaced)
+}
+
+// Explain describes why the container cannot be placed right now and
+// whether it ever could be; see scheduler.Explain.
+func (b *Broker) Explain(container *models.Container) (reason string, feasible bool) {
+	return scheduler.Explain(container, b.Hosts)
+}
+
+// AllocateJob gang-schedules a job: either every container of the job is
+// placed or none is, in which case the job is queued. It returns the placed
+// containers.
+func (b *Broker) AllocateJob(job *models.Job) []*models.Container {
+	if b.tryJob(job) {
+		return job.Containers
+	}
+	b.PendingJobs = append(b.PendingJobs, job)
+	return nil
+}
+
+// tryJob schedules all of the job's containers and rolls back any partial
+// placement.
+func (b *Broker) tryJob(job *models.Job) bool {
+	members := make([]*models.Container, len(job.Containers))
+	copy(members, job.Containers)
+	if len(b.Scheduler.Schedule(members, b.Hosts)) == 0 {
+		return true
+	}
+	for _, container := range job.Containers {
+		if container.HostID != "" {
+			b.Release(container)
+		}
+	}
+	return false
+}
+
+// CancelJob removes a job from the gang queue and reports whether it was
+// still queued.
+func (b *Broker) CancelJob(job *models.Job) bool {
+	for i, pending := range b.PendingJobs {
+		if pending.ID == job.ID {
+			b.PendingJobs = append(b.PendingJobs[:i], b.PendingJobs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RetryPending tries to place the queued gang jobs and containers again,
+// e.g. after capacity has been released, and returns the containers that
+// were placed. Gang jobs are retried first, in submission order.
+func (b *Broker) RetryPending() []*models.Container {
+	var placed []*models.Container
+	remainingJobs := b.PendingJobs[:0]
+	for _, job := range b.PendingJobs {
+		if b.tryJob(job) {
+			placed = append(placed, job.Containers...)
+		} else {
+			remainingJobs = append(remainingJobs, job)
+		}
+	}
+	b.PendingJobs = remainingJobs
+
+	if len(b.Pending) == 0 {
+		return placed
+	}
+
+	// Schedule a copy so strategies that sort their input keep the queue in
+	// submission order.
+	queued := make([]*models.Container, len(b.Pending))
+	copy(queued, b.Pending)
+	unplaced := b.Scheduler.Schedule(queued, b.Hosts)
+
+	stillPending := make(map[string]bool, len(unplaced))
+	for _, container := range unplaced {
+		stillPending[container.ID] = true
+	}
+	remaining := b.Pending[:0]
+	for _, container := range b.Pending {
+		if stillPending[container.ID] {
+			remaining = append(remaining, container)
+		}
+	}
+	b.Pending = remaining
+
+	return append(placed, placedOnly(queued, unplaced)...)
+}
+
+func placedOnly(containers, unplaced []*models.Container) []*models.Container {
+	skip := make(map[string]bool, len(unplaced))
+	for _, container := range unplaced {
+		skip[container.ID] = true
+	}
+	var placed []*models.Container
+	for _, container := range containers {
+		if !skip[container.ID] {
+			placed = append(placed, container)
+		}
+	}
+	return placed
+}
+
+// Host returns the host with the given ID, or nil if there is none.
+func (b *Broker) Host(id string) *models.Host {
+	for _, host := range b.Hosts {
+		if host.ID == id {
+			return host
+		}
+	}
+	return nil
+}
+
+// HostsOf returns every host the container holds resources on.
+func (b *Broker) HostsOf(container *models.Container) []*models.Host {
+	var hosts []*models.Host
+	for _, id := range container.HostIDs() {
+		if host := b.Host(id); host != nil {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// Release frees the resources held by the container on all of its hosts.
+func (b *Broker) Release(container *models.Container) error {
+	hosts := b.HostsOf(container)
+	if len(hosts) == 0 {
+		return fmt.Errorf("container %s is not placed on any host", container.ID)
+	}
+	for _, host := range hosts {
+		host.Release(container.ID)
+	}
+	return nil
 }
 
 func (b *Broker) GetCurrentMetrics() models.Metrics {
-	var cpuUsage, memoryUsage, gpuUsage, ioUsage float64
+	var cpuUsage, memoryUsage, gpuUsage, ioUsage, powerDraw float64
+	var freeSlices, strandedSlices int
 
 	for _, host := range b.Hosts {
 		cpuUsage += host.GetCPUUsage()
 		memoryUsage += host.GetMemoryUsage()
 		gpuUsage += host.GetGPUUsage()
 		ioUsage += host.GetIOUsage()
+		powerDraw += host.GetPowerDraw()
+		free, stranded := host.MIGSlices()
+		freeSlices += free
+		strandedSlices += stranded
 	}
 
 	totalHosts := float64(len(b.Hosts))
@@ -40,10 +195,15 @@ func (b *Broker) GetCurrentMetrics() models.Metrics {
 		return models.NewMetrics(0, 0, 0, 0)
 	}
 
-	return models.NewMetrics(
+	metrics := models.NewMetrics(
 		cpuUsage/totalHosts,
 		memoryUsage/totalHosts,
 		gpuUsage/totalHosts,
 		ioUsage/totalHosts,
 	)
+	metrics.PowerDraw = powerDraw
+	if freeSlices > 0 {
+		metrics.MIGFragmentation = float64(strandedSlices) / float64(freeSlices) * 100
+	}
+	return metrics
 }
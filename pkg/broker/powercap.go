@@ -0,0 +1,104 @@
+package broker
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+)
+
+// PowerCapMode says how the broker keeps the cluster within its power
+// budgets.
+type PowerCapMode int
+
+const (
+	// Refuse turns away placements that would take a budget over its limit
+	// at full GPU clocks.
+	Refuse PowerCapMode = iota
+	// Throttle lowers GPU clocks to hold the draw within the budgets, and
+	// only turns away placements that would exceed one at the lowest clocks.
+	Throttle
+)
+
+func (m PowerCapMode) String() string {
+	if m == Throttle {
+		return "throttle"
+	}
+	return "refuse"
+}
+
+// ParsePowerCapMode parses a mode name as written in scenario files.
+func ParsePowerCapMode(name string) (PowerCapMode, error) {
+	switch name {
+	case "refuse":
+		return Refuse, nil
+	case "throttle":
+		return Throttle, nil
+	}
+	return 0, fmt.Errorf("unknown power cap mode %q, want refuse or throttle", name)
+}
+
+// PowerCap limits the power drawn by each rack and by the whole cluster, in
+// watts; zero leaves that level uncapped.
+type PowerCap struct {
+	Mode          PowerCapMode
+	RackBudget    float64
+	ClusterBudget float64
+}
+
+// SetPowerCap gives every rack, in the order its first host was added, and
+// then the cluster a power budget. Hosts without a rack only count against
+// the cluster budget.
+func (b *Broker) SetPowerCap(config *PowerCap) {
+	throttle := config.Mode == Throttle
+	if config.RackBudget > 0 {
+		racks := map[string]*models.PowerBudget{}
+		for _, host := range b.Hosts {
+			if host.Location.Rack == "" {
+				continue
+			}
+			name := host.Domain(models.RackLevel)
+			budget := racks[name]
+			if budget == nil {
+				budget = &models.PowerBudget{Name: "rack " + name, Limit: config.RackBudget, Throttle: throttle}
+				racks[name] = budget
+				b.Budgets = append(b.Budgets, budget)
+			}
+			budget.Hosts = append(budget.Hosts, host)
+			host.Budgets = append(host.Budgets, budget)
+		}
+	}
+	if config.ClusterBudget > 0 {
+		budget := &models.PowerBudget{Name: "cluster", Limit: config.ClusterBudget, Throttle: throttle, Hosts: b.Hosts}
+		b.Budgets = append(b.Budgets, budget)
+		for _, host := range b.Hosts {
+			host.Budgets = append(host.Budgets, budget)
+		}
+	}
+}
+
+// ApplyPowerCap sets the GPU clocks to the fastest states that hold every
+// throttling budget within its limit. Rack budgets are settled before the
+// cluster budget, which only lowers clocks further where it must. It returns
+// the budgets whose level changed.
+func (b *Broker) ApplyPowerCap() []*models.PowerBudget {
+	held := map[*models.Host]int{}
+	var changed []*models.PowerBudget
+	for _, budget := range b.Budgets {
+		if !budget.Throttle {
+			continue
+		}
+		level := budget.LevelFor(held)
+		for _, host := range budget.Hosts {
+			held[host] = max(held[host], level)
+		}
+		if level != budget.Level {
+			budget.Level = level
+			changed = append(changed, budget)
+		}
+	}
+	if len(changed) > 0 {
+		for _, host := range b.Hosts {
+			host.SetClockState(held[host])
+		}
+	}
+	return changed
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Epoch is the wall-clock instant that virtual time zero maps to. It is fixed
+// so that log timestamps do not depend on when the simulation was started.
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type Event struct {
+	At       time.Duration
+	Action   func()
+	seq      uint64
+	index    int
+	canceled bool
+}
+
+type Engine struct {
+	now   time.Duration
+	seq   uint64
+	queue eventQueue
+}
+
+func NewEngine() *Engine {
+	return &Engine{
+		queue: eventQueue{},
+	}
+}
+
+// Now returns the current virtual time, measured from the start of the simulation.
+func (e *Engine) Now() time.Duration {
+	return e.now
+}
+
+// Clock returns the current virtual time as a wall-clock instant.
+func (e *Engine) Clock() time.Time {
+	return Epoch.Add(e.now)
+}
+
+// Schedule queues action to run after delay of virtual time.
+func (e *Engine) Schedule(delay time.Duration, action func()) *Event {
+	if delay < 0 {
+		delay = 0
+	}
+	return e.ScheduleAt(e.now+delay, action)
+}
+
+// ScheduleAt queues action to run at the given virtual time. Events scheduled
+// for the same instant run in the order they were scheduled.
+func (e *Engine) ScheduleAt(at time.Duration, action func()) *Event {
+	if at < e.now {
+		at = e.now
+	}
+	e.seq++
+	event := &Event{
+		At:     at,
+		Action: action,
+		seq:    e.seq,
+	}
+	heap.Push(&e.queue, event)
+	return event
+}
+
+// Every runs action once per interval, starting one interval from now, until
+// the returned ticker is stopped or the simulation ends.
+func (e *Engine) Every(interval time.Duration, action func()) *Ticker {
+	t := &Ticker{}
+	var tick func()
+	tick = func() {
+		if t.stopped {
+			return
+		}
+		action()
+		if !t.stopped {
+			e.Schedule(interval, tick)
+		}
+	}
+	e.Schedule(interval, tick)
+	return t
+}
+
+func (e *Engine) Cancel(event *Event) {
+	if event == nil || event.canceled {
+		return
+	}
+	event.canceled = true
+	if event.index >= 0 {
+		heap.Remove(&e.queue, event.index)
+	}
+}
+
+// Pending returns the number of events waiting in the queue.
+func (e *Engine) Pending() int {
+	return e.queue.Len()
+}
+
+// Step runs the next queued event and reports whether one was run.
+func (e *Engine) Step() bool {
+	if e.queue.Len() == 0 {
+		return false
+	}
+	event := heap.Pop(&e.queue).(*Event)
+	e.now = event.At
+	event.Action()
+	return true
+}
+
+// Run processes events in virtual-time order until the queue is empty or the
+// next event lies beyond until. The clock is left at until.
+func (e *Engine) Run(until time.Duration) {
+	for e.queue.Len() > 0 && e.queue[0].At <= until {
+		e.Step()
+	}
+	if e.now < until {
+		e.now = until
+	}
+}
+
+type Ticker struct {
+	stopped bool
+}
+
+func (t *Ticker) Stop() {
+	t.stopped = true
+}
+
+type eventQueue []*Event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].At == q[j].At {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].At < q[j].At
+}
+
+func (q eventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *eventQueue) Push(x any) {
+	event := x.(*Event)
+	event.index = len(*q)
+	*q = append(*q, event)
+}
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	n := len(old)
+	event := old[n-1]
+	old[n-1] = nil
+	event.index = -1
+	*q = old[:n-1]
+	return event
+}
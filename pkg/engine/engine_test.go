@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSameTimeEventsRunInScheduleOrder(t *testing.T) {
+	e := NewEngine()
+	var order []int
+	for i := 0; i < 5; i++ {
+		e.Schedule(time.Second, func() { order = append(order, i) })
+	}
+	e.Schedule(0, func() { order = append(order, -1) })
+
+	e.Run(time.Minute)
+
+	want := []int{-1, 0, 1, 2, 3, 4}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestEventsScheduledDuringRunKeepOrder(t *testing.T) {
+	e := NewEngine()
+	var order []string
+	e.Schedule(time.Second, func() {
+		order = append(order, "a")
+		e.Schedule(0, func() { order = append(order, "c") })
+	})
+	e.Schedule(time.Second, func() { order = append(order, "b") })
+
+	e.Run(time.Minute)
+
+	want := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	e := NewEngine()
+	var ran []int
+	first := e.Schedule(time.Second, func() { ran = append(ran, 1) })
+	e.Schedule(2*time.Second, func() { ran = append(ran, 2) })
+	third := e.Schedule(3*time.Second, func() { ran = append(ran, 3) })
+
+	e.Cancel(first)
+	e.Cancel(first)
+	e.Cancel(nil)
+	if e.Pending() != 2 {
+		t.Fatalf("Pending() = %d after cancel, want 2", e.Pending())
+	}
+
+	e.Run(time.Minute)
+	e.Cancel(third)
+
+	if !reflect.DeepEqual(ran, []int{2, 3}) {
+		t.Fatalf("ran = %v, want [2 3]", ran)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	e := NewEngine()
+	var ticks []time.Duration
+	var ticker *Ticker
+	ticker = e.Every(10*time.Second, func() {
+		ticks = append(ticks, e.Now())
+		if len(ticks) == 3 {
+			ticker.Stop()
+		}
+	})
+
+	e.Run(time.Minute)
+
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}
+	if !reflect.DeepEqual(ticks, want) {
+		t.Fatalf("ticks = %v, want %v", ticks, want)
+	}
+	if e.Pending() != 0 {
+		t.Fatalf("Pending() = %d after stop, want 0", e.Pending())
+	}
+}
+
+func TestRunStopsAtHorizon(t *testing.T) {
+	e := NewEngine()
+	var ran []time.Duration
+	for _, at := range []time.Duration{time.Second, 5 * time.Second, 6 * time.Second} {
+		e.ScheduleAt(at, func() { ran = append(ran, e.Now()) })
+	}
+
+	e.Run(5 * time.Second)
+
+	if !reflect.DeepEqual(ran, []time.Duration{time.Second, 5 * time.Second}) {
+		t.Fatalf("ran = %v, want events up to and including the horizon", ran)
+	}
+	if e.Now() != 5*time.Second {
+		t.Fatalf("Now() = %v, want 5s", e.Now())
+	}
+	if e.Pending() != 1 {
+		t.Fatalf("Pending() = %d, want the event beyond the horizon left queued", e.Pending())
+	}
+
+	e.Run(10 * time.Second)
+	if len(ran) != 3 || e.Now() != 10*time.Second {
+		t.Fatalf("after second Run: ran = %v, Now() = %v", ran, e.Now())
+	}
+}
+
+func TestScheduleInThePastRunsNow(t *testing.T) {
+	e := NewEngine()
+	e.Run(time.Minute)
+	var at time.Duration
+	e.ScheduleAt(time.Second, func() { at = e.Now() })
+	e.Schedule(-time.Second, func() {})
+
+	e.Run(2 * time.Minute)
+
+	if at != time.Minute {
+		t.Fatalf("past event ran at %v, want %v", at, time.Minute)
+	}
+}
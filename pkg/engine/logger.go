@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"io"
+	"log"
+)
+
+// NewLogger returns a logger whose lines are stamped with the engine's virtual
+// clock instead of the host's wall clock. The stamp uses the same layout as
+// log.LstdFlags so existing log parsers keep working.
+func NewLogger(w io.Writer, e *Engine) *log.Logger {
+	return log.New(&clockWriter{w: w, engine: e}, "", 0)
+}
+
+type clockWriter struct {
+	w      io.Writer
+	engine *Engine
+}
+
+func (c *clockWriter) Write(p []byte) (int, error) {
+	stamp := c.engine.Clock().Format("2006/01/02 15:04:05 ")
+	if _, err := io.WriteString(c.w, stamp); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
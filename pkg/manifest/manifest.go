@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"gpu-cloudsim/pkg/scenario"
+	"os"
+	"time"
+)
+
+// FileName is the name of the manifest written next to the simulation logs.
+const FileName = "run_manifest.json"
+
+// Manifest records everything needed to reproduce a simulation run exactly:
+// the resolved seed and the full scenario, not just the path it came from.
+type Manifest struct {
+	Seed         int64             `json:"seed"`
+	CreatedAt    time.Time         `json:"created_at"`
+	ScenarioFile string            `json:"scenario_file,omitempty"`
+	Scenario     scenario.Scenario `json:"scenario"`
+}
+
+func Write(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	if err := m.Scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("manifest %s contains an invalid scenario:\n%w", path, err)
+	}
+	return &m, nil
+}
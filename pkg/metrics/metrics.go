@@ -3,6 +3,8 @@ package metrics
 import (
 	"gpu-cloudsim/models"
 	"gpu-cloudsim/pkg/broker"
+	"math"
+	"sort"
 	"sync"
 )
 
@@ -37,3 +39,87 @@ func (m *MetricsCollector) GetLatestMetrics() models.Metrics {
 	}
 	return m.metrics[len(m.metrics)-1]
 }
+
+// Stats summarizes a series of utilization samples.
+type Stats struct {
+	Avg float64 `json:"avg"`
+	P95 float64 `json:"p95"`
+}
+
+type Summary struct {
+	Samples int   `json:"samples"`
+	CPU     Stats `json:"cpu"`
+	Memory  Stats `json:"memory"`
+	GPU     Stats `json:"gpu"`
+	IO      Stats `json:"io"`
+	// Power is the cluster's sampled power draw in watts. The energy used
+	// is metered by the orchestrator as the draw changes, not from these
+	// samples.
+	Power Stats `json:"power_w"`
+	// MIGFragmentation is the share of free MIG slices stranded by the
+	// partition layout, in percent.
+	MIGFragmentation Stats `json:"mig_fragmentation"`
+}
+
+// Summarize reduces the collected samples to averages and 95th percentiles.
+func (m *MetricsCollector) Summarize() Summary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary := Summary{Samples: len(m.metrics)}
+	if len(m.metrics) == 0 {
+		return summary
+	}
+
+	cpu := make([]float64, len(m.metrics))
+	memory := make([]float64, len(m.metrics))
+	gpu := make([]float64, len(m.metrics))
+	io := make([]float64, len(m.metrics))
+	fragmentation := make([]float64, len(m.metrics))
+	power := make([]float64, len(m.metrics))
+	for i, sample := range m.metrics {
+		cpu[i] = sample.CPUUsage
+		memory[i] = sample.MemoryUsage
+		gpu[i] = sample.GPUUsage
+		io[i] = sample.IOUsage
+		fragmentation[i] = sample.MIGFragmentation
+		power[i] = sample.PowerDraw
+	}
+
+	summary.CPU = NewStats(cpu)
+	summary.Memory = NewStats(memory)
+	summary.GPU = NewStats(gpu)
+	summary.IO = NewStats(io)
+	summary.MIGFragmentation = NewStats(fragmentation)
+	summary.Power = NewStats(power)
+	return summary
+}
+
+// NewStats returns the average and 95th percentile of the values.
+func NewStats(values []float64) Stats {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	return Stats{
+		Avg: sum / float64(len(sorted)),
+		P95: Percentile(sorted, 95),
+	}
+}
+
+// Percentile returns the nearest-rank percentile p (0-100) of sorted values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,326 @@
+// Package network models the datacenter network between hosts: host NICs,
+// oversubscribed rack and zone uplinks, and the latency between failure
+// domains. Flows crossing the network share link bandwidth max-min fairly,
+// so congestion stretches transfers and throttles streams.
+//
+// Host-local network I/O demand (models.Container.NetworkIO) is modelled
+// separately, as contention on the host's NIC.
+package network
+
+import (
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/engine"
+	"math"
+	"time"
+)
+
+// Config describes the network fabric.
+type Config struct {
+	// RackOversubscription is the ratio of the NIC bandwidth in a rack to
+	// its uplink bandwidth, and SpineOversubscription the same ratio for a
+	// zone's racks and the zone's uplink. Zero leaves the uplink
+	// unconstrained.
+	RackOversubscription  float64
+	SpineOversubscription float64
+	// StorageBandwidth caps the dataset storage service in MB/s; zero leaves
+	// it unconstrained.
+	StorageBandwidth float64
+	// Latency is the one-way latency between hosts in the same rack, zone,
+	// region, and between regions. Storage is reached at region latency.
+	RackLatency, ZoneLatency, RegionLatency, InterRegionLatency time.Duration
+}
+
+// Flow is traffic between two hosts, or from dataset storage to a host when
+// Src is nil. A transfer moves a fixed amount of data as fast as the network
+// allows; a stream wants a fixed rate for as long as it runs.
+type Flow struct {
+	ID       string
+	Src, Dst *models.Host
+	// Demand is a stream's wanted rate in MB/s; zero marks a transfer.
+	Demand float64
+	// Remaining is the data a transfer still has to move, in MB.
+	Remaining float64
+	// Rate is the bandwidth the flow currently gets, in MB/s.
+	Rate float64
+
+	links []string
+	// start is when the flow's first data arrives, one path latency after
+	// it began; it moves no data before then.
+	start    time.Duration
+	updated  time.Duration
+	done     func()
+	complete *engine.Event
+}
+
+// Throttle returns how much slower than wanted a stream runs, or 1.
+func (f *Flow) Throttle() float64 {
+	if f.Demand == 0 || f.Rate >= f.Demand {
+		return 1
+	}
+	if f.Rate <= 0 {
+		return math.Inf(1)
+	}
+	return f.Demand / f.Rate
+}
+
+// Network tracks the active flows and the bandwidth they get.
+type Network struct {
+	engine   *engine.Engine
+	config   Config
+	capacity map[string]float64 // MB/s per directed link
+	flows    []*Flow
+
+	// OnRatesChanged, if set, is called after stream rates change.
+	OnRatesChanged func()
+}
+
+// New builds the network connecting the hosts. Hosts without NIC bandwidth
+// have unconstrained NICs, and racks and zones get uplinks sized from the
+// NICs below them.
+func New(eng *engine.Engine, hosts []*models.Host, config Config) *Network {
+	n := &Network{engine: eng, config: config, capacity: map[string]float64{}}
+	racks, zones := map[string]float64{}, map[string]float64{}
+	for _, host := range hosts {
+		if host.NICBandwidth > 0 {
+			n.capacity[host.ID+"/up"] = float64(host.NICBandwidth)
+			n.capacity[host.ID+"/down"] = float64(host.NICBandwidth)
+		}
+		racks[host.Domain(models.RackLevel)] += float64(host.NICBandwidth)
+	}
+	seen := map[string]bool{}
+	for _, host := range hosts {
+		rack := host.Domain(models.RackLevel)
+		if seen[rack] {
+			continue
+		}
+		seen[rack] = true
+		uplink := racks[rack]
+		if config.RackOversubscription > 0 {
+			uplink /= config.RackOversubscription
+			n.link(rack, uplink)
+		}
+		zones[host.Domain(models.ZoneLevel)] += uplink
+	}
+	if config.SpineOversubscription > 0 {
+		for zone, uplinks := range zones {
+			n.link(zone, uplinks/config.SpineOversubscription)
+		}
+	}
+	if config.StorageBandwidth > 0 {
+		n.capacity["storage"] = config.StorageBandwidth
+	}
+	return n
+}
+
+// link adds the up and down directions of a domain's uplink. Uplinks of
+// domains whose hosts have unconstrained NICs stay unconstrained too.
+func (n *Network) link(domain string, capacity float64) {
+	if capacity > 0 {
+		n.capacity[domain+"/up"] = capacity
+		n.capacity[domain+"/down"] = capacity
+	}
+}
+
+// path returns the links a flow from src to dst crosses and its latency.
+func (n *Network) path(src, dst *models.Host) ([]string, time.Duration) {
+	var links []string
+	var latency time.Duration
+	if src == nil {
+		links = append(links, "storage", dst.Domain(models.ZoneLevel)+"/down", dst.Domain(models.RackLevel)+"/down")
+		latency = n.config.RegionLatency
+	} else {
+		links = append(links, src.ID+"/up")
+		switch src.Proximity(dst) {
+		case models.HostLevel:
+			return nil, 0
+		case models.RackLevel:
+			latency = n.config.RackLatency
+		case models.ZoneLevel:
+			links = append(links, src.Domain(models.RackLevel)+"/up", dst.Domain(models.RackLevel)+"/down")
+			latency = n.config.ZoneLatency
+		default:
+			links = append(links,
+				src.Domain(models.RackLevel)+"/up", src.Domain(models.ZoneLevel)+"/up",
+				dst.Domain(models.ZoneLevel)+"/down", dst.Domain(models.RackLevel)+"/down")
+			latency = n.config.RegionLatency
+			if src.Location.Region != dst.Location.Region {
+				latency = n.config.InterRegionLatency
+			}
+		}
+	}
+	links = append(links, dst.ID+"/down")
+
+	constrained := links[:0]
+	for _, link := range links {
+		if _, ok := n.capacity[link]; ok {
+			constrained = append(constrained, link)
+		}
+	}
+	return constrained, latency
+}
+
+// Transfer starts moving size MB from src to dst (or from storage when src
+// is nil) and calls done once the data has arrived.
+func (n *Network) Transfer(id string, src, dst *models.Host, size float64, done func()) *Flow {
+	flow := &Flow{ID: id, Src: src, Dst: dst, Remaining: size, done: done}
+	n.add(flow)
+	return flow
+}
+
+// Stream starts a flow that wants demand MB/s from src to dst until stopped.
+func (n *Network) Stream(id string, src, dst *models.Host, demand float64) *Flow {
+	flow := &Flow{ID: id, Src: src, Dst: dst, Demand: demand}
+	n.add(flow)
+	return flow
+}
+
+func (n *Network) add(flow *Flow) {
+	links, latency := n.path(flow.Src, flow.Dst)
+	flow.links = links
+	flow.start = n.engine.Now() + latency
+	flow.updated = n.engine.Now()
+	n.advance()
+	n.flows = append(n.flows, flow)
+	n.rebalance()
+}
+
+// Stop ends a flow early, e.g. when the container it belongs to stops.
+func (n *Network) Stop(flow *Flow) {
+	n.advance()
+	if !n.remove(flow) {
+		return
+	}
+	if flow.complete != nil {
+		n.engine.Cancel(flow.complete)
+	}
+	n.rebalance()
+}
+
+func (n *Network) remove(flow *Flow) bool {
+	for i, f := range n.flows {
+		if f == flow {
+			n.flows = append(n.flows[:i], n.flows[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// advance credits every transfer with the data moved at its current rate
+// since its data started arriving.
+func (n *Network) advance() {
+	now := n.engine.Now()
+	for _, flow := range n.flows {
+		from := max(flow.updated, flow.start)
+		if flow.Demand == 0 && !math.IsInf(flow.Rate, 1) && now > from {
+			flow.Remaining = max(flow.Remaining-flow.Rate*(now-from).Seconds(), 0)
+		}
+		flow.updated = now
+	}
+}
+
+// rebalance shares the link capacity among the flows max-min fairly,
+// raising all unsaturated flows' rates together until a link fills up or a
+// stream gets its demand, then moves the transfers' completions.
+func (n *Network) rebalance() {
+	remaining := make(map[string]float64, len(n.capacity))
+	for link, capacity := range n.capacity {
+		remaining[link] = capacity
+	}
+	frozen := make(map[*Flow]bool, len(n.flows))
+	for _, flow := range n.flows {
+		flow.Rate = 0
+		if len(flow.links) == 0 {
+			// Nothing constrains the flow
+			flow.Rate = flow.Demand
+			if flow.Demand == 0 {
+				flow.Rate = math.Inf(1)
+			}
+			frozen[flow] = true
+		}
+	}
+	for len(frozen) < len(n.flows) {
+		users := map[string]int{}
+		for _, flow := range n.flows {
+			if !frozen[flow] {
+				for _, link := range flow.links {
+					users[link]++
+				}
+			}
+		}
+		step := math.Inf(1)
+		for link, count := range users {
+			step = min(step, remaining[link]/float64(count))
+		}
+		for _, flow := range n.flows {
+			if !frozen[flow] && flow.Demand > 0 {
+				step = min(step, flow.Demand-flow.Rate)
+			}
+		}
+		for _, flow := range n.flows {
+			if frozen[flow] {
+				continue
+			}
+			flow.Rate += step
+			for _, link := range flow.links {
+				remaining[link] -= step
+			}
+		}
+		progressed := false
+		for _, flow := range n.flows {
+			if frozen[flow] {
+				continue
+			}
+			if flow.Demand > 0 && flow.Rate >= flow.Demand-1e-9 {
+				frozen[flow] = true
+			}
+			for _, link := range flow.links {
+				if remaining[link] <= 1e-9*n.capacity[link] {
+					frozen[flow] = true
+				}
+			}
+			progressed = progressed || frozen[flow]
+		}
+		if !progressed {
+			break // rounding left every link a sliver of capacity
+		}
+	}
+
+	streams := false
+	for _, flow := range n.flows {
+		if flow.Demand > 0 {
+			streams = true
+			continue
+		}
+		n.schedule(flow)
+	}
+	if streams && n.OnRatesChanged != nil {
+		n.OnRatesChanged()
+	}
+}
+
+// schedule moves a transfer's completion to when its remaining data will
+// have arrived at the current rate. The path latency is paid once, before
+// the first data arrives, not again on every reschedule.
+func (n *Network) schedule(flow *Flow) {
+	if flow.complete != nil {
+		n.engine.Cancel(flow.complete)
+	}
+	at := max(n.engine.Now(), flow.start)
+	if flow.Rate > 0 && !math.IsInf(flow.Rate, 1) {
+		at += time.Duration(flow.Remaining / flow.Rate * float64(time.Second))
+	}
+	if flow.Rate == 0 && flow.Remaining > 0 {
+		flow.complete = nil // stalled until bandwidth frees up
+		return
+	}
+	flow.complete = n.engine.ScheduleAt(at, func() {
+		n.advance()
+		n.remove(flow)
+		flow.complete = nil
+		n.rebalance()
+		if flow.done != nil {
+			flow.done()
+		}
+	})
+}
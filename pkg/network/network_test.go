@@ -0,0 +1,61 @@
+package network
+
+import (
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/engine"
+	"testing"
+	"time"
+)
+
+func TestTransferPaysLatencyOnce(t *testing.T) {
+	eng := engine.NewEngine()
+	host := models.NewHost("host-1", 8, 16384)
+	host.NICBandwidth = 100
+	n := New(eng, []*models.Host{host}, Config{RegionLatency: time.Second})
+
+	done := map[string]time.Duration{}
+	finish := func(id string) func() {
+		return func() { done[id] = eng.Now() }
+	}
+	first := n.Transfer("first", nil, host, 100, finish("first"))
+	var second *Flow
+	eng.ScheduleAt(1500*time.Millisecond, func() {
+		second = n.Transfer("second", nil, host, 100, finish("second"))
+	})
+
+	eng.Run(time.Minute)
+
+	// The first transfer's data starts arriving after 1s and moves 50 MB
+	// alone before sharing the NIC from 1.5s, so its last 50 MB at 50 MB/s
+	// arrive at 2.5s. The second one's data only arrives from 2.5s, when it
+	// has the NIC to itself.
+	if got, want := done["first"], 2500*time.Millisecond; got != want {
+		t.Errorf("first transfer finished at %v, want %v", got, want)
+	}
+	if got, want := done["second"], 3500*time.Millisecond; got != want {
+		t.Errorf("second transfer finished at %v, want %v", got, want)
+	}
+	if first.Remaining != 0 || second.Remaining != 0 {
+		t.Errorf("remaining data: first %g MB, second %g MB", first.Remaining, second.Remaining)
+	}
+}
+
+func TestNoDataMovesDuringLatency(t *testing.T) {
+	eng := engine.NewEngine()
+	host := models.NewHost("host-1", 8, 16384)
+	host.NICBandwidth = 100
+	n := New(eng, []*models.Host{host}, Config{RegionLatency: 2 * time.Second})
+
+	flow := n.Transfer("t", nil, host, 300, nil)
+	eng.Run(time.Second)
+	n.advance()
+	if flow.Remaining != 300 {
+		t.Fatalf("remaining = %g MB during the latency window, want 300", flow.Remaining)
+	}
+
+	eng.Run(3 * time.Second)
+	n.advance()
+	if flow.Remaining != 200 {
+		t.Fatalf("remaining = %g MB one second after data started, want 200", flow.Remaining)
+	}
+}
@@ -0,0 +1,317 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"slices"
+	"time"
+)
+
+// ConsolidationConfig makes the orchestrator drain lightly loaded hosts onto
+// the others and power down the hosts left idle, booting them again when
+// queued containers or overloaded hosts need the capacity.
+type ConsolidationConfig struct {
+	// Interval is how often hosts are drained and idle hosts powered down.
+	// A host must have stayed empty for a whole interval to power down.
+	Interval time.Duration
+	// DrainLoad is the load below which a host is drained.
+	DrainLoad float64
+	// BootTime is how long a powered-off host takes to come back on.
+	BootTime time.Duration
+}
+
+// consolidate drains the lightly loaded hosts and powers down the idle ones.
+func (o *Orchestrator) consolidate() {
+	migrations := o.Stats.Migrations
+	o.drainLightHosts()
+	if o.Stats.Migrations > migrations {
+		o.updateSlowdowns()
+	}
+	o.powerDownIdle()
+}
+
+// drainLightHosts migrates every container off the hosts below the drain
+// load, least loaded first, packing them onto the most loaded hosts that stay
+// below the target load and already carry more load than the drained host.
+// Hosts with containers that cannot move, or with nowhere for them all to
+// go, are left alone.
+func (o *Orchestrator) drainLightHosts() {
+	v := o.newRebalanceView()
+	hosts := v.ByLoad()
+	drained := map[*models.Host]bool{}
+	for i := len(hosts) - 1; i >= 0; i-- {
+		source := hosts[i]
+		if source.Power != models.PoweredOn || len(source.Containers) == 0 || v.Load(source) >= o.Consolidation.DrainLoad {
+			continue
+		}
+		movable := v.Movable(source)
+		if len(movable) < len(source.Containers) {
+			continue // Pinned or busy containers keep the host on
+		}
+		if !o.drainable(v, source, movable, drained) {
+			continue
+		}
+		drained[source] = true
+		o.Logger.Printf("Draining host %s at %.0f%% load\n", source.ID, v.Load(source)*100)
+		moved := 0
+		for _, container := range movable {
+			dest := o.packTarget(v, container, source, drained)
+			if dest == nil || !v.Migrate(container, source, dest) {
+				break
+			}
+			moved++
+		}
+		if moved < len(movable) {
+			// The host keeps the containers left on it, so it may take
+			// others again and does not count as drained.
+			delete(drained, source)
+			o.Logger.Printf("Stopped draining host %s after moving %d of %d containers\n", source.ID, moved, len(movable))
+			continue
+		}
+		o.Stats.HostsDrained++
+	}
+}
+
+// drainable reports whether the containers can all move off the source
+// now. Each is held on its pack target in turn, so the next one sees the
+// capacity and load already taken, and everything is given back before
+// returning. The migration limits must allow every move, too.
+func (o *Orchestrator) drainable(v *RebalanceView, source *models.Host, containers []*models.Container, drained map[*models.Host]bool) bool {
+	if o.Limits != nil {
+		for _, container := range containers {
+			if reason, _ := o.suppressed(container); reason != "" {
+				return false
+			}
+		}
+		if o.Limits.Budget > 0 && len(o.recentMigrations())+len(containers) > o.Limits.Budget {
+			return false
+		}
+	}
+
+	held := map[*models.Host][]*models.Container{}
+	defer func() {
+		for host, reservations := range held {
+			for _, reservation := range reservations {
+				host.Release(reservation.ID)
+			}
+			v.loads[host] = o.calculateHostLoad(host)
+		}
+	}()
+	for _, container := range containers {
+		dest := o.packTarget(v, container, source, drained)
+		if dest == nil {
+			return false
+		}
+		reservation := newReservation(container, "drain")
+		if err := dest.Allocate(reservation); err != nil {
+			return false
+		}
+		held[dest] = append(held[dest], reservation)
+		v.loads[dest] = o.calculateHostLoad(dest)
+	}
+	return true
+}
+
+// packTarget returns the most loaded host the container can move to that
+// stays below the target load with it and is not being drained, or nil.
+// Only hosts already more loaded than the source qualify: moving onto an
+// empty or lighter host saves nothing and would only be drained back.
+func (o *Orchestrator) packTarget(v *RebalanceView, container *models.Container, source *models.Host, drained map[*models.Host]bool) *models.Host {
+	var best *models.Host
+	for _, host := range v.Destinations(container, source) {
+		if drained[host] || len(host.Containers) == 0 || v.Load(host) <= v.Load(source) {
+			continue
+		}
+		if v.LoadWith(host, container) >= v.target {
+			continue
+		}
+		if best == nil || v.Load(host) > v.Load(best) {
+			best = host
+		}
+	}
+	return best
+}
+
+// powerDownIdle powers down the hosts that have been empty since the last
+// consolidation, unless queued work is waiting for capacity. Queued work
+// also boots the hosts it still needs, as gangs may have been left waiting
+// for hosts that are off.
+func (o *Orchestrator) powerDownIdle() {
+	waiting := len(o.Broker.Pending) > 0 || len(o.Broker.PendingJobs) > 0
+	if waiting {
+		o.wakeForQueue()
+	}
+	for _, host := range o.Broker.Hosts {
+		if host.Power != models.PoweredOn || len(host.Containers) > 0 {
+			delete(o.idle, host)
+			continue
+		}
+		if !o.idle[host] || waiting {
+			o.idle[host] = true
+			continue
+		}
+		delete(o.idle, host)
+		o.meterPower()
+		host.Power = models.PoweredOff
+		o.offSince[host] = o.Engine.Now()
+		o.Stats.PowerDowns++
+		o.Logger.Printf("Host %s powered down\n", host.ID)
+	}
+}
+
+// boot starts bringing a powered-off host back on.
+func (o *Orchestrator) boot(host *models.Host) {
+	o.meterPower()
+	host.Power = models.Booting
+	o.settleOffTime(host)
+	o.Stats.Boots++
+	o.Logger.Printf("Host %s booting, ready in %s\n", host.ID, o.Consolidation.BootTime)
+	o.Engine.Schedule(o.Consolidation.BootTime, func() {
+		o.meterPower()
+		host.Power = models.PoweredOn
+		o.Logger.Printf("Host %s powered on\n", host.ID)
+		o.retryPending()
+	})
+}
+
+// settleOffTime credits the energy a host saved while it was powered off.
+func (o *Orchestrator) settleOffTime(host *models.Host) {
+	since, ok := o.offSince[host]
+	if !ok {
+		return
+	}
+	delete(o.offSince, host)
+	off := o.Engine.Now() - since
+	o.Stats.HostOffTime += off
+	o.Stats.EnergySavedWh += host.IdlePowerDraw() * off.Hours()
+}
+
+// wakeForQueue boots powered-off hosts for queued containers that neither
+// the powered-on hosts nor those already booting could take. A gang job is
+// only covered once all of its containers fit together.
+func (o *Orchestrator) wakeForQueue() {
+	var queued []*models.Container
+	for _, job := range o.Broker.PendingJobs {
+		if slices.ContainsFunc(job.Containers, func(c *models.Container) bool { return c.GPURequest.MultiHost }) {
+			queued = append(queued, job.Containers...)
+			continue
+		}
+		o.wakeForJob(job)
+	}
+	queued = append(queued, o.Broker.Pending...)
+	for _, container := range queued {
+		if container.GPURequest.MultiHost {
+			// Spread containers may need several hosts; add one at a time.
+			if !o.booting() {
+				o.bootFirst(nil)
+			}
+			continue
+		}
+		covered := false
+		for _, host := range o.Broker.Hosts {
+			if host.Power != models.PoweredOff && host.CouldFit(container) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			o.bootFirst(container)
+		}
+	}
+}
+
+// wakeForJob boots the powered-off hosts a queued gang job needs to fit
+// whole alongside the hosts that are on or booting.
+func (o *Orchestrator) wakeForJob(job *models.Job) {
+	for _, host := range o.hostsForJob(job) {
+		o.boot(host)
+	}
+}
+
+// hostsForJob returns the powered-off hosts the job's containers would take
+// if every host were on, or nil if the job would not fit even then. Each
+// container is held on the first host it fits on in turn, hosts that are on
+// or booting first, and everything is given back before returning.
+func (o *Orchestrator) hostsForJob(job *models.Job) []*models.Host {
+	var hosts, off []*models.Host
+	for _, host := range o.Broker.Hosts {
+		if host.Power == models.PoweredOff {
+			off = append(off, host)
+		} else {
+			hosts = append(hosts, host)
+		}
+	}
+	hosts = append(hosts, off...)
+
+	// Hosts that are off or booting take the trial reservations as if they
+	// were on.
+	power := map[*models.Host]models.PowerState{}
+	held := map[*models.Host][]*models.Container{}
+	defer func() {
+		for host, reservations := range held {
+			for _, reservation := range reservations {
+				host.Release(reservation.ID)
+			}
+		}
+		for host, state := range power {
+			host.Power = state
+		}
+	}()
+	for _, host := range hosts {
+		if host.Power != models.PoweredOn {
+			power[host] = host.Power
+			host.Power = models.PoweredOn
+		}
+	}
+
+	var boot []*models.Host
+	for _, container := range job.Containers {
+		i := slices.IndexFunc(hosts, func(host *models.Host) bool { return host.CouldFit(container) })
+		if i < 0 {
+			return nil
+		}
+		dest := hosts[i]
+		reservation := newReservation(container, "wake")
+		if err := dest.Allocate(reservation); err != nil {
+			return nil
+		}
+		if len(held[dest]) == 0 && slices.Contains(off, dest) {
+			boot = append(boot, dest)
+		}
+		held[dest] = append(held[dest], reservation)
+	}
+	return boot
+}
+
+// wakeForLoad boots a powered-off host when rebalancing left hosts
+// overloaded and no host is booting already.
+func (o *Orchestrator) wakeForLoad(v *RebalanceView) {
+	if o.booting() {
+		return
+	}
+	for _, host := range o.Broker.Hosts {
+		if v.Overloaded(host) {
+			o.bootFirst(nil)
+			return
+		}
+	}
+}
+
+func (o *Orchestrator) booting() bool {
+	for _, host := range o.Broker.Hosts {
+		if host.Power == models.Booting {
+			return true
+		}
+	}
+	return false
+}
+
+// bootFirst boots the first powered-off host the container would fit on, or
+// the first powered-off host if container is nil.
+func (o *Orchestrator) bootFirst(container *models.Container) {
+	for _, host := range o.Broker.Hosts {
+		if host.Power == models.PoweredOff && (container == nil || host.CouldFit(container)) {
+			o.boot(host)
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"testing"
+	"time"
+)
+
+func newTestConsolidation() *ConsolidationConfig {
+	return &ConsolidationConfig{Interval: 15 * time.Minute, DrainLoad: 0.3, BootTime: 2 * time.Minute}
+}
+
+func TestSingleLightHostIsNotDrained(t *testing.T) {
+	hosts := newTestHosts(4)
+	o := newTestOrchestrator(t, hosts)
+	o.Consolidation = newTestConsolidation()
+
+	// The only container is light, but every other host is empty, so there
+	// is nothing to pack it onto.
+	c := models.NewContainer("container-1", 1000, 1024, models.GPURequirement{}, 1)
+	o.Run(models.NewCluster(hosts, []*models.Container{c}), 6*time.Hour)
+
+	if o.Stats.Migrations != 0 || o.Stats.HostsDrained != 0 {
+		t.Errorf("%d migrations and %d hosts drained, want none", o.Stats.Migrations, o.Stats.HostsDrained)
+	}
+	if o.Stats.PowerDowns != 3 {
+		t.Errorf("%d power downs, want the 3 empty hosts", o.Stats.PowerDowns)
+	}
+	if c.State != models.Running || c.HostID != "host-1" {
+		t.Errorf("container %v on %q, want running on host-1", c.State, c.HostID)
+	}
+}
+
+func TestLightHostDrainsOntoBusierHost(t *testing.T) {
+	hosts := newTestHosts(2)
+	o := newTestOrchestrator(t, hosts)
+	o.Consolidation = newTestConsolidation()
+
+	// Bin packing puts both on host-1, so move the light one to host-2 and
+	// let consolidation pack it back.
+	busy := models.NewContainer("busy", 2000, 4096, models.GPURequirement{}, 1)
+	light := models.NewContainer("light", 500, 1024, models.GPURequirement{}, 1)
+	cluster := models.NewCluster(hosts, []*models.Container{busy, light})
+	o.Engine.Schedule(time.Minute, func() {
+		hosts[0].Release(light.ID)
+		if err := hosts[1].Allocate(light); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o.Run(cluster, time.Hour)
+
+	if o.Stats.HostsDrained != 1 || light.HostID != "host-1" {
+		t.Errorf("%d hosts drained with light on %q, want host-2 drained onto host-1", o.Stats.HostsDrained, light.HostID)
+	}
+	if o.Stats.Migrations != 1 {
+		t.Errorf("%d migrations, want 1", o.Stats.Migrations)
+	}
+}
+
+func TestGangBootsEnoughHosts(t *testing.T) {
+	hosts := newTestHosts(4)
+	o := newTestOrchestrator(t, hosts)
+	o.Consolidation = newTestConsolidation()
+
+	// host-1 stays busy while the other hosts power down. The gang then
+	// needs two of them, and one booting host must not count as enough.
+	blocker := wholeGPU("blocker")
+	a, b := wholeGPU("worker-a"), wholeGPU("worker-b")
+	a.SubmitTime, b.SubmitTime = 2*time.Hour, 2*time.Hour
+	job := models.NewJob("job-1", []*models.Container{a, b}, true, 0)
+	cluster := models.NewCluster(hosts, []*models.Container{blocker, a, b})
+	cluster.Jobs = []*models.Job{job}
+
+	o.Run(cluster, 4*time.Hour)
+
+	if job.State != models.Running {
+		t.Fatalf("gang state = %v, want Running", job.State)
+	}
+	if o.Stats.Boots != 2 {
+		t.Errorf("%d boots, want 2", o.Stats.Boots)
+	}
+	if got, want := job.WaitTime(o.Engine.Now()), o.Consolidation.BootTime; got != want {
+		t.Errorf("gang waited %v, want the %v boot", got, want)
+	}
+}
+
+func TestGangThatCannotFitBootsNothing(t *testing.T) {
+	hosts := newTestHosts(3)
+	o := newTestOrchestrator(t, hosts)
+	o.Consolidation = newTestConsolidation()
+
+	// Two hosts power down, but the gang needs three free GPUs and only
+	// two hosts could ever be free.
+	blocker := wholeGPU("blocker")
+	var workers []*models.Container
+	for _, id := range []string{"worker-a", "worker-b", "worker-c"} {
+		worker := wholeGPU(id)
+		worker.SubmitTime = 2 * time.Hour
+		workers = append(workers, worker)
+	}
+	job := models.NewJob("job-1", workers, true, 0)
+	cluster := models.NewCluster(hosts, append([]*models.Container{blocker}, workers...))
+	cluster.Jobs = []*models.Job{job}
+
+	o.Run(cluster, 4*time.Hour)
+
+	if job.State != models.Pending || o.Stats.Boots != 0 {
+		t.Errorf("gang %v after %d boots, want it pending with none", job.State, o.Stats.Boots)
+	}
+	for _, host := range hosts[1:] {
+		if host.Power != models.PoweredOff || len(host.Containers) != 0 {
+			t.Errorf("host %s %v with %d containers, want it off and empty", host.ID, host.Power, len(host.Containers))
+		}
+	}
+}
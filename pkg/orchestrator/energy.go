@@ -0,0 +1,53 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"time"
+)
+
+// meterPower charges the energy drawn, and the power cap's share of time,
+// since it last ran. The draw only changes when containers are placed,
+// resized, complete or migrate, GPU clocks change or hosts power up or
+// down, so it must run before each of those and at the end of the run; the
+// draw then held steady over the whole interval.
+func (o *Orchestrator) meterPower() {
+	now := o.Engine.Now()
+	interval := now - o.meteredAt
+	o.meteredAt = now
+	if interval <= 0 {
+		return
+	}
+	o.meterEnergy(interval)
+	o.meterPowerCap(interval)
+}
+
+// meterEnergy charges the hosts and the containers on them for the energy
+// drawn over the interval at their current draw.
+func (o *Orchestrator) meterEnergy(interval time.Duration) {
+	hours := interval.Hours()
+	for _, host := range o.Broker.Hosts {
+		draw := host.PowerBreakdown()
+		if draw.Total() == 0 {
+			continue
+		}
+		o.Stats.Energy = o.Stats.Energy.Add(draw, hours)
+		o.Stats.HostEnergy[host.ID] += draw.Total() * hours
+		for _, container := range host.Containers {
+			o.energyOwner(container).Energy += host.ContainerPowerDraw(container) * hours
+		}
+	}
+}
+
+// energyOwner returns the container a migration reservation holds capacity
+// for, or the container itself.
+func (o *Orchestrator) energyOwner(container *models.Container) *models.Container {
+	if container.State != models.Migrating {
+		return container
+	}
+	for _, m := range o.migrations {
+		if m.reservation == container {
+			return m.container
+		}
+	}
+	return container
+}
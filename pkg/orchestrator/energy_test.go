@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestResizeMetersTheDrawBeforeIt(t *testing.T) {
+	hosts := newTestHosts(1)
+	host := hosts[0]
+	host.BasePower, host.CPUIdlePower, host.CPUMaxPower = 100, 50, 250
+	o := newTestOrchestrator(t, hosts)
+
+	a := models.NewContainer("a", 2000, 1024, models.GPURequirement{}, 1)
+	b := models.NewContainer("b", 2000, 1024, models.GPURequirement{}, 1)
+	cluster := models.NewCluster(hosts, []*models.Container{a, b})
+
+	// a doubles its CPU after an hour, which raises the host's draw and
+	// a's share of it for the second hour only.
+	var hostBefore, hostAfter, aBefore, aAfter float64
+	o.Engine.Schedule(time.Hour, func() {
+		hostBefore, aBefore = host.GetPowerDraw(), host.ContainerPowerDraw(a)
+		o.Resize(func(c *models.Container) (int, int) {
+			if c == a {
+				return 4000, c.MemoryRequest
+			}
+			return c.CPURequest, c.MemoryRequest
+		})
+		hostAfter, aAfter = host.GetPowerDraw(), host.ContainerPowerDraw(a)
+	})
+	o.Run(cluster, 2*time.Hour)
+
+	if hostAfter <= hostBefore || aAfter <= aBefore {
+		t.Fatalf("draw went from %.1f W (a %.1f W) to %.1f W (a %.1f W), want it to grow", hostBefore, aBefore, hostAfter, aAfter)
+	}
+	if got, want := o.Stats.HostEnergy[host.ID], hostBefore+hostAfter; math.Abs(got-want) > 1e-6 {
+		t.Errorf("host energy = %.3f Wh, want %.3f", got, want)
+	}
+	if got, want := a.Energy, aBefore+aAfter; math.Abs(got-want) > 1e-6 {
+		t.Errorf("a's energy = %.3f Wh, want %.3f", got, want)
+	}
+}
@@ -0,0 +1,258 @@
+package orchestrator
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/engine"
+	"gpu-cloudsim/pkg/network"
+	"time"
+)
+
+// MigrationMode says how a migrating container's state reaches its new host.
+type MigrationMode int
+
+const (
+	// StopAndCopy pauses the container for the whole copy.
+	StopAndCopy MigrationMode = iota
+	// PreCopy copies the state while the container keeps running, then
+	// pauses it only to copy again the memory it dirtied meanwhile.
+	PreCopy
+)
+
+func (m MigrationMode) String() string {
+	if m == PreCopy {
+		return "pre-copy"
+	}
+	return "stop-and-copy"
+}
+
+// ParseMigrationMode parses a mode name as written in scenario files.
+func ParseMigrationMode(name string) (MigrationMode, error) {
+	switch name {
+	case "stop-and-copy":
+		return StopAndCopy, nil
+	case "pre-copy":
+		return PreCopy, nil
+	}
+	return 0, fmt.Errorf("unknown migration mode %q, want stop-and-copy or pre-copy", name)
+}
+
+// MigrationConfig describes what migrating a container costs.
+type MigrationConfig struct {
+	Mode MigrationMode
+	// DirtyFraction is the share of its state a pre-copy migration copies
+	// again while the container is paused.
+	DirtyFraction float64
+	// Bandwidth is the rate in MB/s at which state is copied when the run
+	// has no network. Zero uses the slower NIC of the two hosts.
+	Bandwidth float64
+}
+
+// migration is a container on its way between hosts. The destination holds
+// a reservation for the container until its state has arrived, so both hosts
+// hold its resources during the copy.
+type migration struct {
+	container    *models.Container
+	source, dest *models.Host
+	reservation  *models.Container
+	size         float64 // checkpoint size in MB
+	started      time.Duration
+	// paused is when the container stopped running for the final copy.
+	paused time.Duration
+	flow   *network.Flow
+	event  *engine.Event
+}
+
+// checkpointSize is the state a migration copies: the container's memory and
+// GPU memory, in MB.
+func checkpointSize(container *models.Container) float64 {
+	return float64(container.MemoryRequest + container.GPURequest.VRAM*container.GPURequest.Count)
+}
+
+// migrationBandwidth returns the rate at which state is copied from source to
+// dest when the run has no network: the configured bandwidth, or else the
+// slower NIC of the two hosts. Zero means migrations are instant, as they
+// are when no migration cost is configured.
+func (o *Orchestrator) migrationBandwidth(source, dest *models.Host) float64 {
+	switch {
+	case o.Migration == nil:
+		return 0
+	case o.Migration.Bandwidth > 0:
+		return o.Migration.Bandwidth
+	case source.NICBandwidth > 0 && dest.NICBandwidth > 0:
+		return float64(min(source.NICBandwidth, dest.NICBandwidth))
+	}
+	return float64(max(source.NICBandwidth, dest.NICBandwidth))
+}
+
+// migrationConfig returns the migration cost configuration, defaulting to
+// stop-and-copy migrations.
+func (o *Orchestrator) migrationConfig() MigrationConfig {
+	if o.Migration == nil {
+		return MigrationConfig{}
+	}
+	return *o.Migration
+}
+
+// migrateContainer starts moving a running container from sourceHost to
+// destHost. Without a network or a migration bandwidth the move is instant.
+func (o *Orchestrator) migrateContainer(container *models.Container, sourceHost, destHost *models.Host) bool {
+	o.meterPower()
+	if o.Network == nil && o.migrationBandwidth(sourceHost, destHost) == 0 {
+		return o.moveContainer(container, sourceHost, destHost)
+	}
+
+	reservation := newReservation(container, "migration")
+	if err := destHost.Allocate(reservation); err != nil {
+		o.Logger.Printf("Error reserving host %s for container %s: %v", destHost.ID, container.ID, err)
+		return false
+	}
+	m := &migration{
+		container:   container,
+		source:      sourceHost,
+		dest:        destHost,
+		reservation: reservation,
+		size:        checkpointSize(container),
+		started:     o.Engine.Now(),
+	}
+	o.migrations[container.ID] = m
+	o.Stats.Migrations++
+	config := o.migrationConfig()
+	o.Logger.Printf("Container %s starts a %s migration of %.0f MB from host %s to host %s\n",
+		container.ID, config.Mode, m.size, sourceHost.ID, destHost.ID)
+
+	if config.Mode == PreCopy {
+		o.copyState(m, m.size, func() {
+			o.pause(m)
+			o.copyState(m, m.size*config.DirtyFraction, func() { o.finishMigration(m) })
+		})
+	} else {
+		o.pause(m)
+		o.copyState(m, m.size, func() { o.finishMigration(m) })
+	}
+	return true
+}
+
+// newReservation returns a stand-in that holds the container's CPU, memory
+// and GPUs on another host without doing any I/O there.
+func newReservation(container *models.Container, purpose string) *models.Container {
+	reservation := container.Clone()
+	reservation.ID = container.ID + "/" + purpose
+	reservation.HostID, reservation.GPUBindings = "", nil
+	reservation.Group, reservation.Placement = "", nil
+	reservation.DiskIO, reservation.NetworkIO, reservation.SyncTraffic = 0, 0, 0
+	reservation.State = models.Migrating
+	return reservation
+}
+
+// moveContainer moves the container's reservation from the source host to
+// the destination at once.
+func (o *Orchestrator) moveContainer(container *models.Container, sourceHost, destHost *models.Host) bool {
+	sourceHost.Release(container.ID)
+	if err := destHost.Allocate(container); err != nil {
+		o.Logger.Printf("Error migrating container %s to host %s: %v", container.ID, destHost.ID, err)
+		if err := sourceHost.Allocate(container); err != nil {
+			o.Logger.Printf("Error restoring container %s on host %s: %v", container.ID, sourceHost.ID, err)
+		}
+		return false
+	}
+	o.Stats.Migrations++
+
+	o.Logger.Printf("Time: %s, Migrated container %s from host %s to host %s\n",
+		o.Engine.Clock().Format("15:04:05"),
+		container.ID,
+		sourceHost.ID,
+		destHost.ID)
+	o.Logger.Printf("Migrated container %s from host %s to host %s\n",
+		container.ID, sourceHost.ID, destHost.ID)
+	return true
+}
+
+// copyState copies size MB of the migration's state to the destination and
+// then calls done.
+func (o *Orchestrator) copyState(m *migration, size float64, done func()) {
+	o.Stats.MigrationTraffic += size
+	if o.Network != nil {
+		m.flow = o.Network.Transfer("migration/"+m.container.ID, m.source, m.dest, size, func() {
+			m.flow = nil
+			done()
+		})
+		return
+	}
+	delay := time.Duration(size / o.migrationBandwidth(m.source, m.dest) * float64(time.Second))
+	m.event = o.Engine.Schedule(delay, func() {
+		m.event = nil
+		done()
+	})
+}
+
+// pause stops the migrating container for the final copy of its state.
+func (o *Orchestrator) pause(m *migration) {
+	o.meterPower()
+	container := m.container
+	o.advance(container)
+	if state := o.progress[container.ID]; state != nil && state.completion != nil {
+		o.Engine.Cancel(state.completion)
+	}
+	o.stopTracking(container)
+	container.State = models.Migrating
+	m.paused = o.Engine.Now()
+	o.updateSlowdowns()
+}
+
+// finishMigration moves the container onto the reservation its state has
+// arrived at and resumes it there. If it no longer fits there it resumes on
+// the source, and the migration counts as aborted.
+func (o *Orchestrator) finishMigration(m *migration) {
+	o.meterPower()
+	container := m.container
+	delete(o.migrations, container.ID)
+	m.dest.Release(m.reservation.ID)
+	m.source.Release(container.ID)
+	now := o.Engine.Now()
+	downtime := now - m.paused
+	if err := m.dest.Allocate(container); err != nil {
+		o.Logger.Printf("Error migrating container %s to host %s: %v", container.ID, m.dest.ID, err)
+		if err := m.source.Allocate(container); err != nil {
+			o.Logger.Printf("Error restoring container %s on host %s: %v", container.ID, m.source.ID, err)
+		}
+		o.Stats.AbortedMigrations++
+	} else {
+		o.Logger.Printf("Migrated container %s from host %s to host %s in %s\n",
+			container.ID, m.source.ID, m.dest.ID, now-m.started)
+		o.Stats.MigrationTransfers = append(o.Stats.MigrationTransfers, now-m.started)
+		o.Stats.Downtimes = append(o.Stats.Downtimes, downtime)
+	}
+	container.SetupDelay = 0
+	container.Downtime += downtime
+	o.Logger.Printf("Container %s resumes on host %s after %s of downtime\n", container.ID, container.HostID, downtime)
+
+	container.State = models.Running
+	o.running = append(o.running, container)
+	o.progress[container.ID] = &runState{since: now}
+	o.reschedule(container)
+	if o.Network != nil {
+		o.startSync(container)
+	}
+	o.updateSlowdowns()
+}
+
+// abortMigration gives up the container's migration, if any, e.g. because it
+// finished while its state was being pre-copied.
+func (o *Orchestrator) abortMigration(container *models.Container) {
+	m := o.migrations[container.ID]
+	if m == nil {
+		return
+	}
+	o.meterPower()
+	delete(o.migrations, container.ID)
+	if m.flow != nil {
+		o.Network.Stop(m.flow)
+	}
+	if m.event != nil {
+		o.Engine.Cancel(m.event)
+	}
+	m.dest.Release(m.reservation.ID)
+	o.Stats.AbortedMigrations++
+	o.Logger.Printf("Migration of container %s to host %s aborted\n", container.ID, m.dest.ID)
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"testing"
+	"time"
+)
+
+// startPreCopy runs c on host-1 and, a minute in, starts a pre-copy
+// migration of it to host-2 that takes about 45s. during runs 10s into the
+// copy.
+func startPreCopy(t *testing.T, o *Orchestrator, hosts []*models.Host, c *models.Container, during func()) {
+	t.Helper()
+	o.Migration = &MigrationConfig{Mode: PreCopy, DirtyFraction: 0.1, Bandwidth: 100}
+	o.Engine.Schedule(time.Minute, func() {
+		if !o.migrateContainer(c, hosts[0], hosts[1]) {
+			t.Fatal("migration did not start")
+		}
+	})
+	o.Engine.Schedule(time.Minute+10*time.Second, during)
+	o.Run(models.NewCluster(hosts, []*models.Container{c}), time.Hour)
+}
+
+func TestResizeSkipsPreCopiedContainers(t *testing.T) {
+	hosts := newTestHosts(2)
+	o := newTestOrchestrator(t, hosts)
+	c := models.NewContainer("c-1", 1000, 4096, models.GPURequirement{}, 1)
+
+	startPreCopy(t, o, hosts, c, func() {
+		if c.State != models.Running {
+			t.Fatalf("container %v during the pre-copy, want Running", c.State)
+		}
+		o.Resize(func(c *models.Container) (int, int) { return 2 * c.CPURequest, c.MemoryRequest })
+	})
+
+	if c.CPURequest != 1000 || c.HostID != "host-2" {
+		t.Errorf("container with %d millicores on %q, want 1000 on host-2", c.CPURequest, c.HostID)
+	}
+	if len(o.Stats.MigrationTransfers) != 1 || o.Stats.AbortedMigrations != 0 {
+		t.Errorf("%d transfers and %d aborted, want one finished migration", len(o.Stats.MigrationTransfers), o.Stats.AbortedMigrations)
+	}
+}
+
+func TestMigrationFallingBackToSourceIsAborted(t *testing.T) {
+	hosts := newTestHosts(2)
+	o := newTestOrchestrator(t, hosts)
+	c := models.NewContainer("c-1", 1000, 4096, models.GPURequirement{}, 1)
+
+	// The container outgrows what host-2 has left once another container
+	// lands there, so it resumes on host-1.
+	startPreCopy(t, o, hosts, c, func() {
+		other := models.NewContainer("other", 1000, 1024, models.GPURequirement{}, 1)
+		if err := hosts[1].Allocate(other); err != nil {
+			t.Fatal(err)
+		}
+		c.CPURequest = 7500
+	})
+
+	if c.HostID != "host-1" || c.State != models.Running {
+		t.Errorf("container %v on %q, want running on host-1", c.State, c.HostID)
+	}
+	if o.Stats.AbortedMigrations != 1 {
+		t.Errorf("%d aborted migrations, want 1", o.Stats.AbortedMigrations)
+	}
+	if len(o.Stats.MigrationTransfers) != 0 || len(o.Stats.Downtimes) != 0 {
+		t.Errorf("transfers %v and downtimes %v recorded, want none", o.Stats.MigrationTransfers, o.Stats.Downtimes)
+	}
+}
@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/network"
+)
+
+// UseNetwork makes the run's traffic cross the network: containers fetch
+// their datasets before they start, multi-host work streams its gradient
+// synchronization between hosts, and migrations copy container state to the
+// destination host. Congestion slows containers down.
+func (o *Orchestrator) UseNetwork(n *network.Network) {
+	o.Network = n
+	n.OnRatesChanged = o.updateSlowdowns
+}
+
+// fetchDataset copies the container's dataset from storage to each of its
+// hosts and starts the container once every copy has arrived.
+func (o *Orchestrator) fetchDataset(container *models.Container) {
+	hosts := o.Broker.HostsOf(container)
+	waiting := len(hosts)
+	requested := o.Engine.Now()
+	o.Logger.Printf("Container %s fetches its %d MB dataset to hosts %v\n", container.ID, container.DatasetSize, container.HostIDs())
+	for _, host := range hosts {
+		o.Stats.DatasetTraffic += float64(container.DatasetSize)
+		o.Network.Transfer("dataset/"+container.ID, nil, host, float64(container.DatasetSize), func() {
+			if waiting--; waiting > 0 {
+				return
+			}
+			o.fetched[container.ID] = true
+			o.Stats.DatasetWaits = append(o.Stats.DatasetWaits, o.Engine.Now()-requested)
+			if container.State == models.Pending && container.HostID != "" {
+				o.start(container)
+			}
+		})
+	}
+}
+
+// startSync streams a multi-host container's gradient synchronization
+// around the ring of its hosts.
+func (o *Orchestrator) startSync(container *models.Container) {
+	if container.SyncTraffic == 0 {
+		return
+	}
+	if container.IsDistributed() {
+		o.streamRing(container.ID, o.Broker.HostsOf(container), container.SyncTraffic)
+		return
+	}
+	// A gang job synchronizes once all of its workers run.
+	job := o.jobs[container.JobID]
+	if job == nil || !job.Gang || o.streams[job.ID] != nil {
+		return
+	}
+	var hosts []*models.Host
+	for _, member := range job.Containers {
+		if member.State != models.Running {
+			return
+		}
+		if host := o.Broker.Host(member.HostID); host != nil && !containsHost(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	o.streamRing(job.ID, hosts, container.SyncTraffic)
+}
+
+func (o *Orchestrator) streamRing(id string, hosts []*models.Host, demand int) {
+	if len(hosts) < 2 {
+		return
+	}
+	flows := make([]*network.Flow, len(hosts))
+	for i, host := range hosts {
+		next := hosts[(i+1)%len(hosts)]
+		flows[i] = o.Network.Stream(fmt.Sprintf("sync/%s/%d", id, i), host, next, float64(demand))
+	}
+	o.streams[id] = flows
+	o.Logger.Printf("%s synchronizes %d MB/s around hosts %v\n", id, demand, hostIDs(hosts))
+}
+
+// stopSync ends the container's synchronization streams, and its gang
+// job's once any worker stops.
+func (o *Orchestrator) stopSync(container *models.Container) {
+	for _, id := range []string{container.ID, container.JobID} {
+		flows, ok := o.streams[id]
+		if !ok {
+			continue
+		}
+		delete(o.streams, id)
+		for _, flow := range flows {
+			o.Network.Stop(flow)
+		}
+	}
+}
+
+// networkSlowdown returns how much congestion throttles the container's
+// synchronization streams.
+func (o *Orchestrator) networkSlowdown(container *models.Container) float64 {
+	slowdown := 1.0
+	for _, id := range []string{container.ID, container.JobID} {
+		for _, flow := range o.streams[id] {
+			slowdown = max(slowdown, flow.Throttle())
+		}
+	}
+	return slowdown
+}
+
+func containsHost(hosts []*models.Host, host *models.Host) bool {
+	for _, h := range hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func hostIDs(hosts []*models.Host) []string {
+	ids := make([]string, len(hosts))
+	for i, host := range hosts {
+		ids[i] = host.ID
+	}
+	return ids
+}
@@ -1,226 +1,680 @@
 package orchestrator
 
 import (
+	"fmt"
 	"gpu-cloudsim/models"
 	"gpu-cloudsim/pkg/broker"
+	"gpu-cloudsim/pkg/engine"
 	"gpu-cloudsim/pkg/metrics"
+	"gpu-cloudsim/pkg/network"
 	"gpu-cloudsim/pkg/qos"
+	"gpu-cloudsim/pkg/scheduler"
 	"log"
-	"sort"
-	"sync"
 	"time"
 )
 
+const (
+	metricsInterval  = 10 * time.Second
+	qosCheckInterval = time.Second
+)
+
 type Orchestrator struct {
 	Broker           *broker.Broker
 	MetricsCollector *metrics.MetricsCollector
 	QoSMonitor       *qos.QoS
 	Logger           *log.Logger
+	Engine           *engine.Engine
+	// Network carries the run's traffic between hosts; nil leaves traffic
+	// unmodelled. See UseNetwork.
+	Network *network.Network
+	// Migration sets what migrating a container costs; nil, without a
+	// network, makes migrations instant.
+	Migration *MigrationConfig
+	// Rebalance picks the migrations that relieve overloaded hosts, within
+	// Limits if set.
+	Rebalance RebalancePolicy
+	Limits    *MigrationLimits
+	// Consolidation, if set, drains lightly loaded hosts and powers down
+	// idle ones.
+	Consolidation *ConsolidationConfig
+	Stats         Stats
+
+	jobs map[string]*models.Job
+	// running lists the running containers in start order, with the state
+	// needed to track their progress under GPU sharing.
+	running  []*models.Container
+	progress map[string]*runState
+
+	slowdownViolated map[string]bool
+	ioThrottled      map[string]bool
+	netThrottled     map[string]bool
+	clockThrottled   map[string]bool
+	// fetched marks containers whose dataset has arrived on their hosts, and
+	// streams holds the synchronization flows of running multi-host
+	// containers and gang jobs.
+	fetched map[string]bool
+	streams map[string][]*network.Flow
+	// groupHosts lists the host each container of a placement group with a
+	// spread rule started on, in start order.
+	groupHosts map[string][]*models.Host
+	// migrations holds the migrations in flight by container ID.
+	migrations map[string]*migration
+	// lastMigrated holds when each container last started migrating, and
+	// migrationStarts when recent migrations started, for the limits.
+	lastMigrated    map[string]time.Duration
+	migrationStarts []time.Duration
+	// suppressedSince holds, by container ID and limit, the start of the
+	// window in which the container's suppression was last counted.
+	suppressedSince map[string]time.Duration
+	// idle marks powered-on hosts found empty at the last consolidation,
+	// and offSince holds when each powered-off host went down.
+	idle     map[*models.Host]bool
+	offSince map[*models.Host]time.Duration
+	// meteredAt is when energy and power cap time were last charged.
+	meteredAt time.Duration
+}
+
+// runState tracks a running container's progress since its slowdown last
+// changed, and its pending completion.
+type runState struct {
+	since      time.Duration
+	completion *engine.Event
 }
 
-func NewOrchestrator(broker *broker.Broker, qosMonitor *qos.QoS, logger *log.Logger) *Orchestrator {
+// Stats counts the orchestrator's interventions over a run.
+type Stats struct {
+	QoSViolations int
+	Migrations    int
+	// JobGPUCounts maps the number of GPUs a container started with to how
+	// many containers started with that many.
+	JobGPUCounts map[int]int
+	// DistributedStarts counts containers that started spread over hosts.
+	DistributedStarts int
+	// GangWaits holds how long each gang job waited for capacity: until it
+	// started or, for the GangsWaiting still queued, until the end of the
+	// run.
+	GangWaits    []time.Duration
+	GangsWaiting int
+	GangTimeouts int
+	// Unschedulable counts containers failed on submission because no host
+	// could ever run them.
+	Unschedulable int
+	// Reconfigurations counts placements that had to change a GPU's MIG
+	// layout before the container could run.
+	Reconfigurations int
+	// SlowdownViolations counts containers whose slowdown from contention
+	// exceeded the QoS limit at some point.
+	SlowdownViolations int
+	// IOThrottled counts containers that were slowed down by I/O contention
+	// at some point.
+	IOThrottled int
+	// Network traffic in MB, how long migrations took to copy a container's
+	// state and how long containers waited for their datasets.
+	// NetworkThrottled counts containers whose synchronization traffic was
+	// slowed by congestion.
+	MigrationTraffic   float64
+	DatasetTraffic     float64
+	MigrationTransfers []time.Duration
+	DatasetWaits       []time.Duration
+	NetworkThrottled   int
+	// Downtimes holds how long each finished migration paused its container.
+	// AbortedMigrations counts migrations given up because the container
+	// finished during the copy or no longer fit on its destination.
+	Downtimes         []time.Duration
+	AbortedMigrations int
+	// SuppressedMigrations counts the migrations the rebalance policy chose
+	// but the migration limits held back, by limit: cooldown or budget. A
+	// container counts once per cooldown or budget window.
+	SuppressedMigrations map[string]int
+	// Consolidation: hosts drained, powered down and booted again, the
+	// time hosts spent powered off and the energy that saved in Wh.
+	HostsDrained  int
+	PowerDowns    int
+	Boots         int
+	HostOffTime   time.Duration
+	EnergySavedWh float64
+	// Energy sums the hosts' power draw over the run by component, and
+	// HostEnergy by host, in Wh.
+	Energy     models.PowerBreakdown
+	HostEnergy map[string]float64
+	// Power cap: containers queued at submission by the power budgets and
+	// containers slowed by throttled GPU clocks; the GPU time spent busy,
+	// and throttled while busy; the time budgets spent over their limits and
+	// the largest share of its limit a budget drew.
+	PowerCapRefused  int
+	ClockThrottled   int
+	GPUBusyTime      time.Duration
+	GPUThrottledTime time.Duration
+	OverBudgetTime   time.Duration
+	PeakBudgetUse    float64
+	// TopologyStarts counts the starts of containers holding several GPUs on
+	// a host with a described topology, by the slowest link between them.
+	// SuboptimalTopology counts those whose GPUs were worse connected than
+	// the best set the host could have offered.
+	TopologyStarts     map[models.LinkType]int
+	SuboptimalTopology int
+}
+
+func NewOrchestrator(broker *broker.Broker, qosMonitor *qos.QoS, logger *log.Logger, eng *engine.Engine) *Orchestrator {
 	return &Orchestrator{
 		Broker:           broker,
 		MetricsCollector: metrics.NewMetricsCollector(broker),
 		QoSMonitor:       qosMonitor,
 		Logger:           logger,
+		Engine:           eng,
+		Rebalance:        &ThresholdPolicy{},
+		Stats:            Stats{JobGPUCounts: map[int]int{}, TopologyStarts: map[models.LinkType]int{}, SuppressedMigrations: map[string]int{}, HostEnergy: map[string]float64{}},
+		jobs:             map[string]*models.Job{},
+		progress:         map[string]*runState{},
+		groupHosts:       map[string][]*models.Host{},
+		migrations:       map[string]*migration{},
+		lastMigrated:     map[string]time.Duration{},
+		suppressedSince:  map[string]time.Duration{},
+		idle:             map[*models.Host]bool{},
+		offSince:         map[*models.Host]time.Duration{},
+		ioThrottled:      map[string]bool{},
+		netThrottled:     map[string]bool{},
+		clockThrottled:   map[string]bool{},
+		fetched:          map[string]bool{},
+		streams:          map[string][]*network.Flow{},
+		slowdownViolated: map[string]bool{},
 	}
 }
 
-func (o *Orchestrator) Run(containers []*models.Container, duration time.Duration) error {
+// Run admits the cluster's containers as they are submitted and then
+// advances the simulation clock by duration, sampling metrics and checking
+// QoS on the engine's virtual time. Containers submitted at the start are
+// placed as one batch; containers that do not fit wait in the broker's
+// pending queue. Gang jobs are admitted as a unit. Any events scheduled on
+// the engine beforehand (e.g. workload changes) are processed in the same
+// run.
+func (o *Orchestrator) Run(cluster *models.Cluster, duration time.Duration) {
 	o.Logger.Println("Starting orchestrator run")
-	err := o.Broker.AllocateResources(containers)
-	if err != nil {
-		o.Logger.Printf("Error allocating resources: %v", err)
-		return err
+	o.meteredAt = o.Engine.Now()
+
+	for _, job := range cluster.Jobs {
+		o.jobs[job.ID] = job
+	}
+
+	var initial []*models.Container
+	for _, container := range cluster.Containers {
+		container.State = models.Pending
+		if job := o.jobs[container.JobID]; job != nil && job.Gang {
+			continue // Admitted with its job below
+		}
+		if container.SubmitTime <= o.Engine.Now() {
+			initial = append(initial, container)
+			continue
+		}
+		c := container
+		o.Engine.ScheduleAt(c.SubmitTime, func() {
+			o.Logger.Printf("Container %s submitted\n", c.ID)
+			o.admit([]*models.Container{c})
+		})
+	}
+
+	o.admit(initial)
+
+	for _, job := range cluster.Jobs {
+		if !job.Gang {
+			continue
+		}
+		j := job
+		o.Engine.ScheduleAt(j.SubmitTime, func() {
+			o.admitJob(j)
+		})
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(2)
+	o.Logger.Println("Starting metrics collection")
+	o.Engine.Every(metricsInterval, o.collectMetrics)
 
-	// Start metrics collection
-	go func() {
-		defer wg.Done()
-		o.collectMetrics(duration)
-	}()
+	o.Logger.Println("Starting QoS monitoring")
+	o.Engine.Every(qosCheckInterval, o.monitorQoS)
 
-	// Start QoS monitoring
-	go func() {
-		defer wg.Done()
-		o.monitorQoS(duration)
-	}()
+	if o.Consolidation != nil {
+		o.Logger.Println("Starting consolidation")
+		o.Engine.Every(o.Consolidation.Interval, o.consolidate)
+	}
 
-	wg.Wait()
+	o.Engine.Run(o.Engine.Now() + duration)
+	o.meterPower()
+	for _, container := range o.running {
+		o.advance(container)
+	}
+	for _, host := range o.Broker.Hosts {
+		o.settleOffTime(host)
+	}
+	if pending := len(o.Broker.Pending); pending > 0 {
+		o.Logger.Printf("%d containers still pending at end of run\n", pending)
+	}
+	if pending := len(o.Broker.PendingJobs); pending > 0 {
+		o.Logger.Printf("%d gang jobs still pending at end of run\n", pending)
+	}
+	for _, job := range o.Broker.PendingJobs {
+		o.Stats.GangWaits = append(o.Stats.GangWaits, job.WaitTime(o.Engine.Now()))
+		o.Stats.GangsWaiting++
+	}
 	o.Logger.Println("Orchestrator run completed")
-	return nil
 }
 
-func (o *Orchestrator) collectMetrics(duration time.Duration) {
-	o.Logger.Println("Starting metrics collection")
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	end := time.Now().Add(duration)
-
-	for {
-		select {
-		case <-ticker.C:
-			metrics := o.MetricsCollector.CollectMetrics()
-			o.MetricsCollector.AddMetrics(metrics)
-			o.Logger.Printf("Time: %s, CPU: %.2f%%, Memory: %.2f%%, GPU: %.2f%%\n",
-				time.Now().Format("15:04:05"),
-				metrics.CPUUsage,
-				metrics.MemoryUsage,
-				metrics.GPUUsage)
-		default:
-			if time.Now().After(end) {
-				return
-			}
+// admitJob gang-schedules a job. If it cannot be placed whole it waits in
+// the broker's gang queue until capacity frees up or its timeout expires.
+func (o *Orchestrator) admitJob(job *models.Job) {
+	o.Logger.Printf("Gang job %s submitted with %d containers\n", job.ID, len(job.Containers))
+	for _, container := range job.Containers {
+		if reason, feasible := o.Broker.Explain(container); !feasible {
+			o.Stats.Unschedulable += len(job.Containers)
+			o.failJob(job, fmt.Sprintf("unschedulable: container %s %s", container.ID, reason))
+			return
 		}
 	}
+	o.meterPower()
+	placed := o.Broker.AllocateJob(job)
+	if len(placed) > 0 {
+		o.startAll(placed)
+		return
+	}
+
+	o.Logger.Printf("Gang job %s queued (%d gang jobs pending)\n", job.ID, len(o.Broker.PendingJobs))
+	if o.Consolidation != nil {
+		o.wakeForQueue()
+	}
+	if job.GangTimeout > 0 {
+		o.Engine.Schedule(job.GangTimeout, func() {
+			o.timeoutJob(job)
+		})
+	}
 }
 
-func (o *Orchestrator) monitorQoS(duration time.Duration) {
-	o.Logger.Println("Starting QoS monitoring")
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
-
-	end := time.Now().Add(duration)
-
-	for {
-		select {
-		case <-ticker.C:
-			metrics := o.MetricsCollector.GetLatestMetrics()
-			if !o.QoSMonitor.Monitor(metrics, o.Logger) {
-				// QoS violated, trigger reallocation
-				o.TriggerReallocation()
-			}
-		default:
-			if time.Now().After(end) {
-				return
-			}
-		}
+// timeoutJob fails a gang job that is still waiting for capacity.
+func (o *Orchestrator) timeoutJob(job *models.Job) {
+	if !o.Broker.CancelJob(job) {
+		return
 	}
+	o.Stats.GangTimeouts++
+	o.failJob(job, fmt.Sprintf("gang timed out after waiting %s", o.Engine.Now()-job.SubmitTime))
 }
 
-func (o *Orchestrator) TriggerReallocation() {
-	o.Logger.Println("Triggering reallocation due to QoS violation")
+// failJob fails a gang job that never started, and all of its containers.
+func (o *Orchestrator) failJob(job *models.Job, reason string) {
+	now := o.Engine.Now()
+	job.State = models.Failed
+	job.StartTime = now
+	for _, container := range job.Containers {
+		container.State = models.Failed
+		container.StartTime = now
+		container.EndTime = now
+		container.Reason = reason
+	}
+	o.Logger.Printf("Gang job %s failed: %s\n", job.ID, reason)
+}
 
-	// Get all hosts and their current load
-	hosts := o.Broker.Hosts
-	hostLoads := make(map[*models.Host]float64)
+// admit asks the broker to place the containers and starts every container
+// that was placed. The rest stay queued in the broker.
+func (o *Orchestrator) admit(containers []*models.Container) {
+	var schedulable []*models.Container
+	for _, container := range containers {
+		if reason, feasible := o.Broker.Explain(container); !feasible {
+			o.reject(container, reason)
+			continue
+		}
+		schedulable = append(schedulable, container)
+	}
+	if len(schedulable) == 0 {
+		return
+	}
 
-	for _, host := range hosts {
-		load := o.calculateHostLoad(host)
-		hostLoads[host] = load
+	o.meterPower()
+	placed := o.Broker.AllocateResources(schedulable)
+	o.startAll(placed)
+	for _, container := range schedulable {
+		if container.State == models.Pending && container.Reason == scheduler.PowerCapped {
+			o.Stats.PowerCapRefused++
+		}
 	}
+	if queued := len(schedulable) - len(placed); queued > 0 {
+		o.Logger.Printf("Unable to place %d containers, queued (%d pending)\n", queued, len(o.Broker.Pending))
+		if o.Consolidation != nil {
+			o.wakeForQueue()
+		}
+	}
+}
 
-	// Sort hosts by load (descending)
-	sortedHosts := make([]*models.Host, len(hosts))
-	copy(sortedHosts, hosts)
-	sort.Slice(sortedHosts, func(i, j int) bool {
-		return hostLoads[sortedHosts[i]] > hostLoads[sortedHosts[j]]
-	})
+// reject fails a container that no host could ever run.
+func (o *Orchestrator) reject(container *models.Container, reason string) {
+	now := o.Engine.Now()
+	container.State = models.Failed
+	container.StartTime = now
+	container.EndTime = now
+	container.Reason = "unschedulable: " + reason
+	o.Stats.Unschedulable++
+	o.Logger.Printf("Container %s is unschedulable: %s\n", container.ID, reason)
+}
 
-	// Try to migrate containers from the most loaded hosts
-	for _, sourceHost := range sortedHosts {
-		if hostLoads[sourceHost] < 0.8 { // Don't migrate if load is below 80%
-			break
-		}
+// retryPending places queued containers that fit now that capacity was freed.
+func (o *Orchestrator) retryPending() {
+	o.meterPower()
+	o.startAll(o.Broker.RetryPending())
+	if o.Consolidation != nil {
+		o.wakeForQueue()
+	}
+}
 
-		for _, container := range sourceHost.Containers {
-			if destHost := o.findSuitableHost(container, hostLoads); destHost != nil {
-				o.migrateContainer(container, sourceHost, destHost)
+func (o *Orchestrator) startAll(containers []*models.Container) {
+	for _, container := range containers {
+		o.start(container)
+	}
+}
 
-				// Update load values
-				hostLoads[sourceHost] = o.calculateHostLoad(sourceHost)
-				hostLoads[destHost] = o.calculateHostLoad(destHost)
+func (o *Orchestrator) start(container *models.Container) {
+	if delay := container.SetupDelay; delay > 0 {
+		// The container holds its resources while its GPU is reconfigured.
+		container.SetupDelay = 0
+		o.Stats.Reconfigurations++
+		o.Logger.Printf("Container %s waits %s for MIG reconfiguration on hosts %v\n", container.ID, delay, container.HostIDs())
+		o.Engine.Schedule(delay, func() {
+			if container.State == models.Pending && container.HostID != "" {
+				o.start(container)
+			}
+		})
+		return
+	}
+	if o.Network != nil && container.DatasetSize > 0 && !o.fetched[container.ID] {
+		// The container holds its resources while its dataset arrives.
+		o.fetchDataset(container)
+		return
+	}
+	o.meterPower()
+	container.State = models.Running
+	container.StartTime = o.Engine.Now()
+	container.Reason = ""
+	o.Stats.JobGPUCounts[len(container.GPUBindings)]++
+	if container.IsDistributed() {
+		o.Stats.DistributedStarts++
+	}
+	o.recordTopology(container)
+	if host := o.Broker.Host(container.HostID); host != nil && container.Spreads() {
+		o.groupHosts[container.Group] = append(o.groupHosts[container.Group], host)
+	}
+	if job := o.jobs[container.JobID]; job != nil && job.State == models.Pending {
+		job.State = models.Running
+		job.StartTime = o.Engine.Now()
+		if job.Gang {
+			o.Stats.GangWaits = append(o.Stats.GangWaits, job.WaitTime(o.Engine.Now()))
+			o.Logger.Printf("Gang job %s started after waiting %s\n", job.ID, job.WaitTime(o.Engine.Now()))
+		}
+	}
+	o.Logger.Printf("Container %s started on hosts %v with %d GPUs after queueing for %s\n",
+		container.ID, container.HostIDs(), len(container.GPUBindings), container.QueueDelay(o.Engine.Now()))
+
+	container.Slowdown = 1
+	container.MaxSlowdown = max(container.MaxSlowdown, 1)
+	o.running = append(o.running, container)
+	o.progress[container.ID] = &runState{since: o.Engine.Now()}
+	o.reschedule(container)
+	if o.Network != nil {
+		o.startSync(container)
+	}
+	o.updateSlowdowns()
+}
 
-				o.Logger.Printf("Migrated container %s from host %s to host %s\n",
-					container.ID, sourceHost.ID, destHost.ID)
+// recordTopology counts how well connected the container's GPUs are on each
+// of its hosts.
+func (o *Orchestrator) recordTopology(container *models.Container) {
+	for _, host := range o.Broker.HostsOf(container) {
+		got, best, ok := host.TopologyOf(container)
+		if !ok {
+			continue
+		}
+		o.Stats.TopologyStarts[got]++
+		if got > best {
+			o.Stats.SuboptimalTopology++
+			o.Logger.Printf("Container %s got GPUs linked by %s on host %s, %s was possible\n", container.ID, got, host.ID, best)
+		}
+	}
+}
 
-				// Break if source host is no longer overloaded
-				if hostLoads[sourceHost] < 0.8 {
-					break
-				}
+// DomainConcentration returns, for each level of the datacenter hierarchy
+// with more than one failure domain, how concentrated the placement groups
+// asked to spread ended up: the largest share of a group's container starts
+// that landed in a single domain, in percent, averaged over the groups with
+// at least two starts.
+func (o *Orchestrator) DomainConcentration() map[models.Level]float64 {
+	concentration := map[models.Level]float64{}
+	for _, level := range models.Levels {
+		domains := map[string]bool{}
+		for _, host := range o.Broker.Hosts {
+			domains[host.Domain(level)] = true
+		}
+		if len(domains) < 2 {
+			continue
+		}
+		total, groups := 0.0, 0
+		for _, hosts := range o.groupHosts {
+			if len(hosts) < 2 {
+				continue
 			}
+			starts, largest := map[string]int{}, 0
+			for _, host := range hosts {
+				starts[host.Domain(level)]++
+				largest = max(largest, starts[host.Domain(level)])
+			}
+			total += float64(largest) / float64(len(hosts)) * 100
+			groups++
+		}
+		if groups > 0 {
+			concentration[level] = total / float64(groups)
 		}
 	}
+	return concentration
 }
 
-func (o *Orchestrator) calculateHostLoad(host *models.Host) float64 {
-	totalCPU := float64(host.CPUCores)
-	totalMemory := float64(host.Memory)
-	usedCPU := 0.0
-	usedMemory := 0.0
+// advance credits a running container with the work done since its last
+// update at its current slowdown.
+func (o *Orchestrator) advance(container *models.Container) {
+	state := o.progress[container.ID]
+	if state == nil {
+		return
+	}
+	now := o.Engine.Now()
+	container.Progress += time.Duration(float64(now-state.since) / container.Slowdown)
+	state.since = now
+}
 
-	for _, container := range host.Containers {
-		usedCPU += float64(container.CPURequest)
-		usedMemory += float64(container.MemoryRequest)
+// reschedule moves a running container's completion to when its remaining
+// work finishes at its current slowdown.
+func (o *Orchestrator) reschedule(container *models.Container) {
+	state := o.progress[container.ID]
+	if state == nil || container.ExpectedRuntime <= 0 {
+		return
+	}
+	if state.completion != nil {
+		o.Engine.Cancel(state.completion)
 	}
+	remaining := max(container.ExpectedRuntime-container.Progress, 0)
+	state.completion = o.Engine.Schedule(time.Duration(float64(remaining)*container.Slowdown), func() {
+		o.complete(container)
+	})
+}
 
-	cpuLoad := usedCPU / totalCPU
-	memoryLoad := usedMemory / totalMemory
+// updateSlowdowns recomputes how much contention for shared GPUs, I/O and
+// the network, and power-capped GPU clocks, slow each running container down
+// after its co-tenants, flows or clocks changed, and moves the completions of
+// those whose slowdown changed.
+func (o *Orchestrator) updateSlowdowns() {
+	o.capPower()
+	for _, container := range o.running {
+		slowdown, ioSlowdown, netSlowdown := 1.0, 1.0, o.networkSlowdown(container)
+		clockSlowdown := 1.0
+		for _, host := range o.Broker.HostsOf(container) {
+			slowdown = max(slowdown, host.Slowdown(container))
+			ioSlowdown = max(ioSlowdown, host.IOSlowdown(container))
+			clockSlowdown = max(clockSlowdown, host.ClockSlowdown(container))
+		}
+		slowdown = max(slowdown, netSlowdown)
+		if ioSlowdown > 1 && !o.ioThrottled[container.ID] {
+			o.ioThrottled[container.ID] = true
+			o.Stats.IOThrottled++
+			o.Logger.Printf("Container %s throttled %.2fx by I/O contention\n", container.ID, ioSlowdown)
+		}
+		if netSlowdown > 1 && !o.netThrottled[container.ID] {
+			o.netThrottled[container.ID] = true
+			o.Stats.NetworkThrottled++
+			o.Logger.Printf("Container %s throttled %.2fx by network congestion\n", container.ID, netSlowdown)
+		}
+		if clockSlowdown > 1 && !o.clockThrottled[container.ID] {
+			o.clockThrottled[container.ID] = true
+			o.Stats.ClockThrottled++
+			o.Logger.Printf("Container %s throttled %.2fx by power-capped GPU clocks\n", container.ID, clockSlowdown)
+		}
+		if slowdown == container.Slowdown {
+			continue
+		}
 
-	// Return the higher of CPU or memory load
-	if cpuLoad > memoryLoad {
-		return cpuLoad
+		o.advance(container)
+		container.Slowdown = slowdown
+		container.MaxSlowdown = max(container.MaxSlowdown, slowdown)
+		o.reschedule(container)
+
+		if !o.QoSMonitor.ContainerMet(container) && !o.slowdownViolated[container.ID] {
+			o.slowdownViolated[container.ID] = true
+			o.Stats.SlowdownViolations++
+			o.Logger.Printf("Container %s slowed down %.2fx by contention, above the QoS limit\n", container.ID, slowdown)
+		}
 	}
+}
 
-	return memoryLoad
+// complete releases a finished container's resources.
+func (o *Orchestrator) complete(container *models.Container) {
+	if !container.IsActive() {
+		return
+	}
+	o.meterPower()
+	o.abortMigration(container)
+	o.advance(container)
+	o.stopTracking(container)
+	if err := o.Broker.Release(container); err != nil {
+		o.Logger.Printf("Error releasing container %s: %v", container.ID, err)
+	}
+	container.State = models.Completed
+	container.EndTime = o.Engine.Now()
+	o.Logger.Printf("Container %s completed after %s\n", container.ID, container.ActualRuntime(o.Engine.Now()))
+	if job := o.jobs[container.JobID]; job != nil && jobFinished(job) {
+		job.State = models.Completed
+		o.Logger.Printf("Job %s completed\n", job.ID)
+	}
+
+	o.retryPending()
+	o.updateSlowdowns()
 }
 
-func (o *Orchestrator) findSuitableHost(container *models.Container, hostLoads map[*models.Host]float64) *models.Host {
-	for _, host := range o.Broker.Hosts {
-		if hostLoads[host] >= 0.8 {
-			continue // Skip overloaded hosts
+func (o *Orchestrator) stopTracking(container *models.Container) {
+	delete(o.progress, container.ID)
+	if o.Network != nil {
+		o.stopSync(container)
+	}
+	for i, running := range o.running {
+		if running.ID == container.ID {
+			o.running = append(o.running[:i], o.running[i+1:]...)
+			return
 		}
+	}
+}
 
-		if canAllocate(container, host) {
-			return host
+// Summary reduces the metrics sampled during the run.
+func (o *Orchestrator) Summary() metrics.Summary {
+	return o.MetricsCollector.Summarize()
+}
+
+func jobFinished(job *models.Job) bool {
+	for _, container := range job.Containers {
+		if container.State != models.Completed {
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
-func (o *Orchestrator) migrateContainer(container *models.Container, sourceHost, destHost *models.Host) {
-	// Remove container from source host
-	sourceHost.RemoveContainer(container.ID)
-
-	// Add container to destination host
-	destHost.AddContainer(container)
+func (o *Orchestrator) collectMetrics() {
+	metrics := o.MetricsCollector.CollectMetrics()
+	o.MetricsCollector.AddMetrics(metrics)
+	if o.capPower() {
+		o.updateSlowdowns()
+	}
+	o.Logger.Printf("Time: %s, CPU: %.2f%%, Memory: %.2f%%, GPU: %.2f%%\n",
+		o.Engine.Clock().Format("15:04:05"),
+		metrics.CPUUsage,
+		metrics.MemoryUsage,
+		metrics.GPUUsage)
+}
 
-	// Update container's GPU if necessary
-	if len(destHost.GPUs) > 0 {
-		container.GPURequest = destHost.GPUs[0]
+func (o *Orchestrator) monitorQoS() {
+	metrics := o.MetricsCollector.GetLatestMetrics()
+	if !o.QoSMonitor.Monitor(metrics, o.Logger) {
+		// QoS violated, trigger reallocation
+		o.Stats.QoSViolations++
+		o.TriggerReallocation()
 	}
+}
 
-	o.Logger.Printf("Time: %s, Migrated container %s from host %s to host %s\n",
-		time.Now().Format("15:04:05"),
-		container.ID,
-		sourceHost.ID,
-		destHost.ID)
+// Resize changes the CPU and memory requests of the containers placed on
+// each host to the sizes size returns, as their workloads change. size is
+// called for every container so that random sizes stay reproducible, but
+// migrating containers keep their size, as their reservation on the
+// destination was sized when the migration started. Growth
+// stays within the power budgets, and throttling budgets lower the clocks
+// at once to hold it.
+func (o *Orchestrator) Resize(size func(container *models.Container) (cpuRequest, memoryRequest int)) {
+	o.meterPower()
+	for _, host := range o.Broker.Hosts {
+		for _, container := range host.Containers {
+			cpuRequest, memoryRequest := size(container)
+			if container.State == models.Migrating || o.migrations[container.ID] != nil {
+				continue // Paused, reserved or pre-copied while its state moves
+			}
+			host.Resize(container, cpuRequest, memoryRequest)
+		}
+	}
+	if o.capPower() {
+		o.updateSlowdowns()
+	}
 }
 
-func canAllocate(container *models.Container, host *models.Host) bool {
-	// Check CPU cores (convert millicores to cores)
-	if float64(host.CPUCores) < float64(container.CPURequest)/1000 {
-		return false
+// TriggerReallocation lets the rebalancing policy migrate containers away
+// from overloaded hosts.
+func (o *Orchestrator) TriggerReallocation() {
+	o.Logger.Println("Triggering reallocation due to QoS violation")
+	migrations := o.Stats.Migrations
+
+	v := o.newRebalanceView()
+	o.Rebalance.Rebalance(v)
+	if o.Consolidation != nil {
+		o.wakeForLoad(v)
 	}
 
-	// Check Memory
-	if host.Memory < container.MemoryRequest {
-		return false
+	// Migrations change which containers share GPUs.
+	if o.Stats.Migrations > migrations {
+		o.updateSlowdowns()
 	}
+}
 
-	// Check if any GPU on the host meets the requirements
-	for _, gpu := range host.GPUs {
-		if gpu.CUDACores >= container.GPURequest.CUDACores &&
-			gpu.VRAM >= container.GPURequest.VRAM &&
-			gpu.MemoryBandwidth >= container.GPURequest.MemoryBandwidth {
-			return true // Found a suitable GPU
+// calculateHostLoad returns the host's load once the containers migrating
+// away from it have left.
+func (o *Orchestrator) calculateHostLoad(host *models.Host) float64 {
+	cpu, memory := host.AllocatedCPU, host.AllocatedMemory
+	for _, m := range o.migrations {
+		if m.source == host {
+			cpu -= m.container.CPURequest
+			memory -= m.container.MemoryRequest
 		}
 	}
+	cpuLoad := float64(cpu) / float64(host.CPUCores*1000)
+	memoryLoad := float64(memory) / float64(host.Memory)
 
-	return false
+	// Return the higher of CPU or memory load
+	if cpuLoad > memoryLoad {
+		return cpuLoad
+	}
+
+	return memoryLoad
 }
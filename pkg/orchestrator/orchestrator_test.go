@@ -0,0 +1,95 @@
+package orchestrator
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/broker"
+	"gpu-cloudsim/pkg/engine"
+	"gpu-cloudsim/pkg/qos"
+	"gpu-cloudsim/pkg/scheduler"
+	"io"
+	"testing"
+	"time"
+)
+
+// newTestOrchestrator returns a bin-packing orchestrator over the hosts that
+// discards its log.
+func newTestOrchestrator(t *testing.T, hosts []*models.Host) *Orchestrator {
+	t.Helper()
+	strategy, err := scheduler.New("BinPacking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := broker.NewBroker(strategy)
+	for _, host := range hosts {
+		b.AddHost(host)
+	}
+	eng := engine.NewEngine()
+	return NewOrchestrator(b, qos.NewQoS(100, 100, 100, 100, 0), engine.NewLogger(io.Discard, eng), eng)
+}
+
+// newTestHosts returns count hosts with 8 cores, 32 GB and one GPU each.
+func newTestHosts(count int) []*models.Host {
+	var hosts []*models.Host
+	for i := 1; i <= count; i++ {
+		host := models.NewHost(fmt.Sprintf("host-%d", i), 8, 32768)
+		host.AddGPU(models.NewGPU(fmt.Sprintf("gpu-%d", i), 8192, 256, 24576, 900, 30, 300))
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// wholeGPU returns a container that takes a whole test GPU.
+func wholeGPU(id string) *models.Container {
+	return models.NewContainer(id, 1000, 4096, models.GPURequirement{Count: 1, CUDACores: 8192, VRAM: 8192}, 1)
+}
+
+func TestGangStillWaitingAtEndOfRun(t *testing.T) {
+	hosts := newTestHosts(2)
+	o := newTestOrchestrator(t, hosts)
+
+	// A container that never ends holds one GPU, so a gang needing both
+	// GPUs waits from its submission at 1h to the end of the run.
+	blocker := wholeGPU("blocker")
+	a, b := wholeGPU("worker-a"), wholeGPU("worker-b")
+	a.SubmitTime, b.SubmitTime = time.Hour, time.Hour
+	job := models.NewJob("job-1", []*models.Container{a, b}, true, 0)
+	cluster := models.NewCluster(hosts, []*models.Container{blocker, a, b})
+	cluster.Jobs = []*models.Job{job}
+
+	o.Run(cluster, 3*time.Hour)
+
+	if job.State != models.Pending {
+		t.Fatalf("gang state = %v, want Pending", job.State)
+	}
+	if o.Stats.GangsWaiting != 1 || len(o.Stats.GangWaits) != 1 {
+		t.Fatalf("GangsWaiting = %d, GangWaits = %v, want one waiting gang", o.Stats.GangsWaiting, o.Stats.GangWaits)
+	}
+	if got := o.Stats.GangWaits[0]; got != 2*time.Hour {
+		t.Errorf("wait = %v, want 2h", got)
+	}
+}
+
+func TestTimedOutGangWait(t *testing.T) {
+	hosts := newTestHosts(2)
+	o := newTestOrchestrator(t, hosts)
+
+	blocker := wholeGPU("blocker")
+	a, b := wholeGPU("worker-a"), wholeGPU("worker-b")
+	a.SubmitTime, b.SubmitTime = time.Hour, time.Hour
+	job := models.NewJob("job-1", []*models.Container{a, b}, true, 30*time.Minute)
+	cluster := models.NewCluster(hosts, []*models.Container{blocker, a, b})
+	cluster.Jobs = []*models.Job{job}
+
+	o.Run(cluster, 3*time.Hour)
+
+	if job.State != models.Failed || o.Stats.GangTimeouts != 1 {
+		t.Fatalf("gang state = %v with %d timeouts, want Failed after one", job.State, o.Stats.GangTimeouts)
+	}
+	if got := job.WaitTime(o.Engine.Now()); got != 30*time.Minute {
+		t.Errorf("WaitTime = %v, want the 30m timeout", got)
+	}
+	if o.Stats.GangsWaiting != 0 || len(o.Stats.GangWaits) != 0 {
+		t.Errorf("GangsWaiting = %d, GangWaits = %v, want none", o.Stats.GangsWaiting, o.Stats.GangWaits)
+	}
+}
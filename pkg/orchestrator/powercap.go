@@ -0,0 +1,54 @@
+package orchestrator
+
+import "time"
+
+// capPower lowers or restores the GPU clocks to hold the throttling power
+// budgets within their limits and reports whether any clocks changed.
+func (o *Orchestrator) capPower() bool {
+	o.meterPower()
+	changed := o.Broker.ApplyPowerCap()
+	for _, budget := range changed {
+		if budget.Level == 0 {
+			o.Logger.Printf("Power budget %s within its %.1f kW limit, GPU clocks restored\n", budget.Name, budget.Limit/1000)
+			continue
+		}
+		o.Logger.Printf("Power budget %s holds GPU clocks at DVFS state %d, drawing %.1f kW of %.1f kW\n",
+			budget.Name, budget.Level, budget.Draw()/1000, budget.Limit/1000)
+	}
+	return len(changed) > 0
+}
+
+// meterPowerCap accounts for the interval the time budgets spent over their
+// limits and the time GPUs and containers spent throttled, and the largest
+// share of its limit a budget drew, at the current draw and clocks.
+func (o *Orchestrator) meterPowerCap(interval time.Duration) {
+	if len(o.Broker.Budgets) == 0 {
+		return
+	}
+	for _, budget := range o.Broker.Budgets {
+		draw := budget.Draw()
+		o.Stats.PeakBudgetUse = max(o.Stats.PeakBudgetUse, draw/budget.Limit)
+		if draw > budget.Limit {
+			o.Stats.OverBudgetTime += interval
+		}
+	}
+	for _, host := range o.Broker.Hosts {
+		for _, gpu := range host.GPUs {
+			if gpu.AllocatedCUDACores == 0 {
+				continue
+			}
+			o.Stats.GPUBusyTime += interval
+			if gpu.Throttled() {
+				o.Stats.GPUThrottledTime += interval
+			}
+		}
+	}
+	for _, container := range o.running {
+		for _, host := range o.Broker.HostsOf(container) {
+			if host.ClockSlowdown(container) > 1 {
+				container.Throttled += interval
+				break
+			}
+		}
+	}
+}
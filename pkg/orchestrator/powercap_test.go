@@ -0,0 +1,37 @@
+package orchestrator
+
+import (
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/broker"
+	"testing"
+	"time"
+)
+
+func TestResizeThrottlesAtOnce(t *testing.T) {
+	hosts := newTestHosts(1)
+	host := hosts[0]
+	host.BasePower, host.CPUIdlePower, host.CPUMaxPower = 100, 50, 250
+	o := newTestOrchestrator(t, hosts)
+	// The busy GPU and 1 core draw 475 W at full clock.
+	o.Broker.SetPowerCap(&broker.PowerCap{Mode: broker.Throttle, ClusterBudget: 480})
+	budget := o.Broker.Budgets[0]
+
+	c := wholeGPU("c-1")
+	// Growing to 4 cores only fits the budget at lower clocks, which must
+	// take effect with the growth rather than at the next metrics sample.
+	o.Engine.Schedule(5*time.Minute+3*time.Second, func() {
+		o.Resize(func(*models.Container) (int, int) { return 4000, c.MemoryRequest })
+		if budget.Level == 0 || budget.Draw() > budget.Limit {
+			t.Errorf("after growing: level %d, draw %.1f W of %.1f W, want throttled within the limit",
+				budget.Level, budget.Draw(), budget.Limit)
+		}
+	})
+	o.Run(models.NewCluster(hosts, []*models.Container{c}), time.Hour)
+
+	if c.CPURequest != 4000 {
+		t.Errorf("CPU request %d, want 4000", c.CPURequest)
+	}
+	if o.Stats.OverBudgetTime != 0 || o.Stats.PeakBudgetUse > 1 {
+		t.Errorf("%v over budget with peak use %.3f, want none", o.Stats.OverBudgetTime, o.Stats.PeakBudgetUse)
+	}
+}
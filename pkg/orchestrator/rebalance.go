@@ -0,0 +1,334 @@
+package orchestrator
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"sort"
+	"time"
+)
+
+// DefaultLoadThreshold is the host load at and above which rebalancing
+// moves containers away from a host, and below which a host may receive
+// them, unless MigrationLimits set separate levels.
+const DefaultLoadThreshold = 0.8
+
+// MigrationLimits damp rebalancing so that containers do not bounce between
+// hosts.
+type MigrationLimits struct {
+	// TriggerLoad is the host load at and above which containers are moved
+	// off a host, and TargetLoad the load below which moving stops. Hosts
+	// only receive containers that leave them below TargetLoad. Zero uses
+	// the default threshold.
+	TriggerLoad float64
+	TargetLoad  float64
+	// Cooldown is how long a migrated container stays on its new host
+	// before it may migrate again.
+	Cooldown time.Duration
+	// Budget caps the migrations started within any Window; zero leaves
+	// them unlimited.
+	Budget int
+	Window time.Duration
+}
+
+// RebalancePolicy decides which containers to migrate, and where, when the
+// cluster violates its QoS thresholds. Policies start migrations through the
+// view, which keeps host loads current as they do.
+type RebalancePolicy interface {
+	Rebalance(v *RebalanceView)
+}
+
+// rebalancePolicies maps the policy names accepted in scenario files to
+// constructors.
+var rebalancePolicies = map[string]func() RebalancePolicy{
+	"threshold":         func() RebalancePolicy { return &ThresholdPolicy{} },
+	"least-loaded":      func() RebalancePolicy { return &LeastLoadedPolicy{} },
+	"minimum-migration": func() RebalancePolicy { return &MinimumMigrationPolicy{} },
+	"consolidation":     func() RebalancePolicy { return &ConsolidationPolicy{} },
+}
+
+// NewRebalancePolicy returns a fresh rebalancing policy by name.
+func NewRebalancePolicy(name string) (RebalancePolicy, error) {
+	factory, ok := rebalancePolicies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown rebalance policy %q (known policies: %v)", name, RebalancePolicyNames())
+	}
+	return factory(), nil
+}
+
+// RebalancePolicyNames returns the known policy names in sorted order.
+func RebalancePolicyNames() []string {
+	names := make([]string, 0, len(rebalancePolicies))
+	for name := range rebalancePolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RebalanceView is what a rebalancing policy sees of the cluster.
+type RebalanceView struct {
+	o     *Orchestrator
+	loads map[*models.Host]float64
+	// trigger and target are the load levels at which draining a host
+	// starts and stops.
+	trigger, target float64
+}
+
+func (o *Orchestrator) newRebalanceView() *RebalanceView {
+	v := &RebalanceView{o: o, loads: map[*models.Host]float64{}, trigger: DefaultLoadThreshold, target: DefaultLoadThreshold}
+	if o.Limits != nil {
+		if o.Limits.TriggerLoad > 0 {
+			v.trigger = o.Limits.TriggerLoad
+		}
+		if o.Limits.TargetLoad > 0 {
+			v.target = o.Limits.TargetLoad
+		}
+	}
+	for _, host := range o.Broker.Hosts {
+		v.loads[host] = o.calculateHostLoad(host)
+	}
+	return v
+}
+
+// Overloaded reports whether the host's load calls for moving containers
+// off it.
+func (v *RebalanceView) Overloaded(host *models.Host) bool {
+	return v.loads[host] >= v.trigger
+}
+
+// Relieved reports whether enough containers have left the host.
+func (v *RebalanceView) Relieved(host *models.Host) bool {
+	return v.loads[host] < v.target
+}
+
+// Hosts returns the cluster's hosts.
+func (v *RebalanceView) Hosts() []*models.Host {
+	return v.o.Broker.Hosts
+}
+
+// Load returns the host's load: the larger of its CPU and memory allocation
+// ratios, not counting containers already migrating away.
+func (v *RebalanceView) Load(host *models.Host) float64 {
+	return v.loads[host]
+}
+
+// LoadWith returns what the host's load would be with the container added.
+func (v *RebalanceView) LoadWith(host *models.Host, container *models.Container) float64 {
+	cpu := float64(container.CPURequest) / float64(host.CPUCores*1000)
+	memory := float64(container.MemoryRequest) / float64(host.Memory)
+	return v.loads[host] + max(cpu, memory)
+}
+
+// ByLoad returns the hosts, most loaded first.
+func (v *RebalanceView) ByLoad() []*models.Host {
+	sorted := make([]*models.Host, len(v.Hosts()))
+	copy(sorted, v.Hosts())
+	sort.Slice(sorted, func(i, j int) bool {
+		return v.loads[sorted[i]] > v.loads[sorted[j]]
+	})
+	return sorted
+}
+
+// Movable returns the containers on the host that may migrate: running,
+// confined to the host and not already migrating.
+func (v *RebalanceView) Movable(host *models.Host) []*models.Container {
+	var movable []*models.Container
+	for _, container := range host.Containers {
+		if container.IsDistributed() {
+			continue // Spread containers are pinned to their hosts
+		}
+		if container.State != models.Running || v.o.migrations[container.ID] != nil {
+			continue // Still setting up on this host, or already leaving it
+		}
+		movable = append(movable, container)
+	}
+	return movable
+}
+
+// Destinations returns the hosts below the target load the container fits
+// on, other than source. With migration limits, the container must also
+// leave them below the target load. Hosts closest to the source in the datacenter
+// hierarchy come first, and the container's placement rules apply as they
+// did when it was placed.
+func (v *RebalanceView) Destinations(container *models.Container, source *models.Host) []*models.Host {
+	hosts := make([]*models.Host, len(v.Hosts()))
+	copy(hosts, v.Hosts())
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return source.Proximity(hosts[i]) > source.Proximity(hosts[j])
+	})
+	var destinations []*models.Host
+	for _, host := range models.RankHosts(container, hosts) {
+		if host == source || v.loads[host] >= v.target {
+			continue // Skip overloaded hosts
+		}
+		if v.o.Limits != nil && v.LoadWith(host, container) >= v.target {
+			continue // It would become a candidate for draining
+		}
+		if host.CanFit(container) {
+			destinations = append(destinations, host)
+		}
+	}
+	return destinations
+}
+
+// Migrate starts moving the container from source to dest and reports
+// whether it did. A container the limits hold back is counted as suppressed
+// once per cooldown or budget window, however many destinations and QoS
+// checks try it meanwhile.
+func (v *RebalanceView) Migrate(container *models.Container, source, dest *models.Host) bool {
+	if reason, since := v.o.suppressed(container); reason != "" {
+		key := container.ID + "/" + reason
+		if counted, ok := v.o.suppressedSince[key]; !ok || counted != since {
+			v.o.suppressedSince[key] = since
+			v.o.Stats.SuppressedMigrations[reason]++
+		}
+		return false
+	}
+	if !v.o.migrateContainer(container, source, dest) {
+		return false
+	}
+	v.o.lastMigrated[container.ID] = v.o.Engine.Now()
+	v.o.migrationStarts = append(v.o.migrationStarts, v.o.Engine.Now())
+	v.loads[source] = v.o.calculateHostLoad(source)
+	v.loads[dest] = v.o.calculateHostLoad(dest)
+	return true
+}
+
+// suppressed returns why the migration limits hold back moving the
+// container now: "cooldown" or "budget", or "" if they allow it. since is
+// when the window holding it back opened: its last migration for a
+// cooldown, the oldest migration counted against the budget otherwise.
+func (o *Orchestrator) suppressed(container *models.Container) (reason string, since time.Duration) {
+	if o.Limits == nil {
+		return "", 0
+	}
+	now := o.Engine.Now()
+	if last, ok := o.lastMigrated[container.ID]; ok && now-last < o.Limits.Cooldown {
+		return "cooldown", last
+	}
+	if recent := o.recentMigrations(); o.Limits.Budget > 0 && len(recent) >= o.Limits.Budget {
+		return "budget", recent[0]
+	}
+	return "", 0
+}
+
+// recentMigrations returns when the migrations counted against the budget
+// started, forgetting those that have left its window.
+func (o *Orchestrator) recentMigrations() []time.Duration {
+	now := o.Engine.Now()
+	recent := o.migrationStarts[:0]
+	for _, start := range o.migrationStarts {
+		if now-start < o.Limits.Window {
+			recent = append(recent, start)
+		}
+	}
+	o.migrationStarts = recent
+	return recent
+}
+
+// ThresholdPolicy moves containers off each overloaded host, in the order
+// they were placed there, to the nearest host with room that is not
+// overloaded, until the host is relieved.
+type ThresholdPolicy struct{}
+
+func (p *ThresholdPolicy) Rebalance(v *RebalanceView) {
+	drain(v, func(container *models.Container, destinations []*models.Host) *models.Host {
+		return destinations[0]
+	})
+}
+
+// LeastLoadedPolicy works like ThresholdPolicy but sends each container to
+// the least loaded host it fits on, spreading load across the cluster.
+type LeastLoadedPolicy struct{}
+
+func (p *LeastLoadedPolicy) Rebalance(v *RebalanceView) {
+	drain(v, func(container *models.Container, destinations []*models.Host) *models.Host {
+		best := destinations[0]
+		for _, host := range destinations[1:] {
+			if v.Load(host) < v.Load(best) {
+				best = host
+			}
+		}
+		return best
+	})
+}
+
+// ConsolidationPolicy works like ThresholdPolicy but sends each container to
+// the most loaded host that stays below the threshold with it, packing load
+// onto few hosts so that the others stay lightly loaded.
+type ConsolidationPolicy struct{}
+
+func (p *ConsolidationPolicy) Rebalance(v *RebalanceView) {
+	drain(v, func(container *models.Container, destinations []*models.Host) *models.Host {
+		var best *models.Host
+		for _, host := range destinations {
+			if v.LoadWith(host, container) >= v.target {
+				continue
+			}
+			if best == nil || v.Load(host) > v.Load(best) {
+				best = host
+			}
+		}
+		if best == nil {
+			return destinations[0]
+		}
+		return best
+	})
+}
+
+// drain moves containers off each overloaded host until it is relieved,
+// sending each to the destination pick chooses.
+func drain(v *RebalanceView, pick func(*models.Container, []*models.Host) *models.Host) {
+	for _, source := range v.ByLoad() {
+		if !v.Overloaded(source) {
+			break
+		}
+		for _, container := range v.Movable(source) {
+			destinations := v.Destinations(container, source)
+			if len(destinations) == 0 || !v.Migrate(container, source, pick(container, destinations)) {
+				continue
+			}
+			if v.Relieved(source) {
+				break
+			}
+		}
+	}
+}
+
+// MinimumMigrationPolicy relieves each overloaded host with as few
+// migrations, and as little copied state, as it can: it moves the smallest
+// container whose departure alone relieves the host, or else the largest
+// movable one, and repeats until the host is relieved.
+type MinimumMigrationPolicy struct{}
+
+func (p *MinimumMigrationPolicy) Rebalance(v *RebalanceView) {
+	for _, source := range v.ByLoad() {
+		if !v.Overloaded(source) {
+			break
+		}
+		for !v.Relieved(source) {
+			var enough, largest *models.Container
+			var enoughDest, largestDest *models.Host
+			for _, container := range v.Movable(source) {
+				destinations := v.Destinations(container, source)
+				if len(destinations) == 0 {
+					continue
+				}
+				relief := v.LoadWith(source, container) - v.Load(source)
+				if v.Load(source)-relief < v.target &&
+					(enough == nil || checkpointSize(container) < checkpointSize(enough)) {
+					enough, enoughDest = container, destinations[0]
+				}
+				if largest == nil || relief > v.LoadWith(source, largest)-v.Load(source) {
+					largest, largestDest = container, destinations[0]
+				}
+			}
+			if enough != nil {
+				largest, largestDest = enough, enoughDest
+			}
+			if largest == nil || !v.Migrate(largest, source, largestDest) {
+				break
+			}
+		}
+	}
+}
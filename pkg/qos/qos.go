@@ -3,7 +3,6 @@ package qos
 import (
 	"gpu-cloudsim/models"
 	"log"
-	"time"
 )
 
 type QoS struct {
@@ -12,17 +11,27 @@ type QoS struct {
 	memoryUsageThreshold float64
 	gpuUsageThreshold    float64
 	ioUsageThreshold     float64
+
+	// Per-container limit on the slowdown from contention; zero disables it.
+	maxSlowdown float64
 }
 
-func NewQoS(cpuUsageThreshold, memoryUsageThreshold, gpuUsageThreshold, ioUsageThreshold float64) *QoS {
+func NewQoS(cpuUsageThreshold, memoryUsageThreshold, gpuUsageThreshold, ioUsageThreshold, maxSlowdown float64) *QoS {
 	return &QoS{
 		cpuUsageThreshold:    cpuUsageThreshold,
 		memoryUsageThreshold: memoryUsageThreshold,
 		gpuUsageThreshold:    gpuUsageThreshold,
 		ioUsageThreshold:     ioUsageThreshold,
+		maxSlowdown:          maxSlowdown,
 	}
 }
 
+// ContainerMet reports whether the container's current slowdown from
+// contention for shared GPUs and I/O is within the per-container limit.
+func (q *QoS) ContainerMet(c *models.Container) bool {
+	return q.maxSlowdown == 0 || c.Slowdown <= q.maxSlowdown
+}
+
 func (q *QoS) Monitor(metrics models.Metrics, logger *log.Logger) bool {
 	violations := 0
 
@@ -45,7 +54,7 @@ func (q *QoS) Monitor(metrics models.Metrics, logger *log.Logger) bool {
 	}
 
 	if violations > 0 {
-		logger.Printf("Time: %s, QoS Violations: %d\n", time.Now().Format("15:04:05"), violations)
+		logger.Printf("QoS Violations: %d\n", violations)
 		return false // QoS requirements not met
 	}
 
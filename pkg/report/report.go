@@ -0,0 +1,802 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/metrics"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comparison collects the outcome of every strategy run on one scenario.
+type Comparison struct {
+	Scenario string           `json:"scenario"`
+	Seed     int64            `json:"seed"`
+	Duration string           `json:"duration"`
+	Results  []StrategyResult `json:"results"`
+}
+
+type StrategyResult struct {
+	Strategy            string               `json:"strategy"`
+	Rebalance           string               `json:"rebalance,omitempty"` // policy, when the scenario compares several
+	Metrics             metrics.Summary      `json:"metrics"`
+	QoSViolations       int                  `json:"qos_violations"`
+	Migrations          int                  `json:"migrations"`
+	Containers          int                  `json:"containers"`
+	UnplacedContainers  int                  `json:"unplaced_containers"`
+	CompletedContainers int                  `json:"completed_containers"`
+	QueueDelay          DelayStats           `json:"queue_delay"`
+	JobGPUCounts        map[int]int          `json:"job_gpu_counts"`
+	DistributedJobs     int                  `json:"distributed_jobs"`
+	GangsStarted        int                  `json:"gangs_started"`
+	GangsTimedOut       int                  `json:"gangs_timed_out"`
+	GangsWaiting        int                  `json:"gangs_waiting"`
+	GangWait            DelayStats           `json:"gang_wait"`
+	Reconfigurations    int                  `json:"mig_reconfigurations"`
+	Slowdown            metrics.Stats        `json:"slowdown"`
+	SlowdownViolations  int                  `json:"slowdown_violations"`
+	IOThrottled         int                  `json:"io_throttled"`
+	Network             *NetworkResult       `json:"network,omitempty"`
+	Migration           *MigrationResult     `json:"migration,omitempty"`
+	Consolidation       *ConsolidationResult `json:"consolidation,omitempty"`
+	Energy              *EnergyResult        `json:"energy,omitempty"`
+	PowerCap            *PowerCapResult      `json:"power_cap,omitempty"`
+	Unschedulable       int                  `json:"unschedulable"`
+	// CooldownSuppressed and BudgetSuppressed count migrations the
+	// migration limits held back, once per container and window.
+	CooldownSuppressed int `json:"migrations_suppressed_cooldown"`
+	BudgetSuppressed   int `json:"migrations_suppressed_budget"`
+	// TopologyLinks counts multi-GPU starts on hosts with a described GPU
+	// topology by the slowest link between their GPUs; SuboptimalTopology
+	// counts those that could have been better connected.
+	TopologyLinks      map[string]int `json:"topology_links,omitempty"`
+	SuboptimalTopology int            `json:"suboptimal_topology"`
+	// DomainConcentration holds, per datacenter level, the largest share in
+	// percent of a spread placement group's containers that started in a
+	// single failure domain, averaged over groups.
+	DomainConcentration map[string]float64 `json:"domain_concentration,omitempty"`
+	// UnplacedReasons counts the containers left unplaced at the end of the
+	// run by the reason they were not placed.
+	UnplacedReasons  map[string]int    `json:"unplaced_reasons,omitempty"`
+	ContainerResults []ContainerResult `json:"container_results"`
+}
+
+// NetworkResult summarizes the traffic between hosts, when the network is
+// modelled.
+type NetworkResult struct {
+	MigrationTrafficGB float64    `json:"migration_traffic_gb"`
+	DatasetTrafficGB   float64    `json:"dataset_traffic_gb"`
+	MigrationTransfer  DelayStats `json:"migration_transfer"`
+	DatasetWait        DelayStats `json:"dataset_wait"`
+	// Throttled counts containers whose synchronization traffic was slowed
+	// by congestion.
+	Throttled int `json:"throttled"`
+}
+
+// MigrationResult summarizes what migrations cost, when they are not
+// instant.
+type MigrationResult struct {
+	TrafficGB float64    `json:"traffic_gb"`
+	Duration  DelayStats `json:"duration"`
+	Downtime  DelayStats `json:"downtime"`
+	// TotalDowntimeSeconds sums the downtime of all finished migrations.
+	TotalDowntimeSeconds float64 `json:"total_downtime_s"`
+	// Aborted counts migrations given up because the container finished
+	// during the copy or no longer fit on its destination.
+	Aborted int `json:"aborted"`
+}
+
+// ConsolidationResult summarizes host draining and power-down, when
+// consolidation is on.
+type ConsolidationResult struct {
+	HostsDrained int `json:"hosts_drained"`
+	PowerDowns   int `json:"power_downs"`
+	Boots        int `json:"boots"`
+	// HostTimeOff is the share of host time spent powered off, in percent.
+	HostTimeOff    float64 `json:"host_time_off_pct"`
+	EnergySavedKWh float64 `json:"energy_saved_kwh"`
+}
+
+// EnergyResult breaks down the energy the hosts used over a run.
+type EnergyResult struct {
+	TotalKWh    float64 `json:"total_kwh"`
+	PlatformKWh float64 `json:"platform_kwh"`
+	CPUKWh      float64 `json:"cpu_kwh"`
+	MemoryKWh   float64 `json:"memory_kwh"`
+	GPUKWh      float64 `json:"gpu_kwh"`
+	// AvgPowerKW is the average draw over the run.
+	AvgPowerKW float64 `json:"avg_power_kw"`
+	// ContainerKWh is the energy attributed to containers; the rest was
+	// drawn by capacity nobody held.
+	ContainerKWh float64 `json:"container_kwh"`
+	// PerCompletedKWh is the energy used per completed container.
+	PerCompletedKWh float64            `json:"per_completed_kwh"`
+	Host            metrics.Stats      `json:"host_kwh_stats"`
+	Hosts           map[string]float64 `json:"host_kwh"`
+}
+
+// PowerCapResult summarizes how the power budgets held back placements and
+// GPU clocks, when the cluster is power capped.
+type PowerCapResult struct {
+	// Refused counts containers queued at submission by the budgets, and
+	// Throttled containers slowed by throttled GPU clocks.
+	Refused   int `json:"refused"`
+	Throttled int `json:"throttled"`
+	// GPUTimeThrottled is the share of busy GPU time spent below full
+	// clock, in percent; ContainerThrottledHours sums the time containers
+	// ran throttled.
+	GPUTimeThrottled        float64 `json:"gpu_time_throttled_pct"`
+	ContainerThrottledHours float64 `json:"container_throttled_h"`
+	// OverBudgetSeconds sums the time budgets drew more than their limits,
+	// and PeakBudgetUse is the largest share of its limit a budget drew, in
+	// percent.
+	OverBudgetSeconds float64 `json:"over_budget_s"`
+	PeakBudgetUse     float64 `json:"peak_budget_use_pct"`
+}
+
+// DelayStats summarizes per-container delays, in seconds of simulated time.
+type DelayStats struct {
+	AvgSeconds float64 `json:"avg_s"`
+	P95Seconds float64 `json:"p95_s"`
+	MaxSeconds float64 `json:"max_s"`
+}
+
+// ContainerResult is the final state of one container, with times in seconds
+// of simulated time since the start of the run.
+type ContainerResult struct {
+	ID                string   `json:"id"`
+	State             string   `json:"state"`
+	SubmitSeconds     float64  `json:"submit_s"`
+	QueueDelaySeconds float64  `json:"queue_delay_s"`
+	RuntimeSeconds    float64  `json:"runtime_s"`
+	GPUsRequested     int      `json:"gpus_requested"`
+	Hosts             []string `json:"hosts,omitempty"`
+	GPUs              []string `json:"gpus,omitempty"`
+	AvgSlowdown       float64  `json:"avg_slowdown"`
+	MaxSlowdown       float64  `json:"max_slowdown"`
+	DowntimeSeconds   float64  `json:"downtime_s,omitempty"`
+	EnergyKWh         float64  `json:"energy_kwh"`
+	ThrottledSeconds  float64  `json:"throttled_s,omitempty"`
+	Reason            string   `json:"reason,omitempty"`
+}
+
+// AddContainers records the final state of every container and derives the
+// container counts, queueing delay and slowdown statistics from it.
+// Containers that would only have been submitted after the run ended are
+// left out.
+func (r *StrategyResult) AddContainers(containers []*models.Container, now time.Duration) {
+	var delays, slowdowns []float64
+	for _, container := range containers {
+		if container.SubmitTime > now {
+			continue
+		}
+		r.Containers++
+		switch container.State {
+		case models.Pending, models.Failed:
+			r.UnplacedContainers++
+			if container.Reason != "" {
+				if r.UnplacedReasons == nil {
+					r.UnplacedReasons = map[string]int{}
+				}
+				r.UnplacedReasons[container.Reason]++
+			}
+		case models.Completed:
+			r.CompletedContainers++
+		}
+
+		delay := container.QueueDelay(now).Seconds()
+		delays = append(delays, delay)
+		if container.Progress > 0 {
+			slowdowns = append(slowdowns, container.AvgSlowdown(now))
+		}
+		r.ContainerResults = append(r.ContainerResults, ContainerResult{
+			ID:                container.ID,
+			State:             container.State.String(),
+			SubmitSeconds:     container.SubmitTime.Seconds(),
+			QueueDelaySeconds: delay,
+			RuntimeSeconds:    container.ActualRuntime(now).Seconds(),
+			GPUsRequested:     container.GPURequest.Count,
+			Hosts:             container.HostIDs(),
+			GPUs:              container.GPUIDs(),
+			AvgSlowdown:       container.AvgSlowdown(now),
+			MaxSlowdown:       max(container.MaxSlowdown, 1),
+			DowntimeSeconds:   container.Downtime.Seconds(),
+			EnergyKWh:         container.Energy / 1000,
+			ThrottledSeconds:  container.Throttled.Seconds(),
+			Reason:            container.Reason,
+		})
+	}
+	r.QueueDelay = newDelayStats(delays)
+	if len(slowdowns) > 0 {
+		r.Slowdown = metrics.NewStats(slowdowns)
+	}
+}
+
+// AddGangWaits records how long each gang job waited for capacity, waiting
+// of which were still queued when the run ended.
+func (r *StrategyResult) AddGangWaits(waits []time.Duration, waiting, timedOut int) {
+	r.GangsStarted = len(waits) - waiting
+	r.GangsWaiting = waiting
+	r.GangsTimedOut = timedOut
+	r.GangWait = newDelayStats(durationSeconds(waits))
+}
+
+// AddNetwork records the traffic between hosts; migration state and
+// datasets are given in MB.
+func (r *StrategyResult) AddNetwork(migrationTraffic, datasetTraffic float64, transfers, datasetWaits []time.Duration, throttled int) {
+	r.Network = &NetworkResult{
+		MigrationTrafficGB: migrationTraffic / 1024,
+		DatasetTrafficGB:   datasetTraffic / 1024,
+		MigrationTransfer:  newDelayStats(durationSeconds(transfers)),
+		DatasetWait:        newDelayStats(durationSeconds(datasetWaits)),
+		Throttled:          throttled,
+	}
+}
+
+// AddMigrations records how long migrations took to copy their state, in MB,
+// and how long they paused their containers.
+func (r *StrategyResult) AddMigrations(traffic float64, durations, downtimes []time.Duration, aborted int) {
+	seconds := durationSeconds(downtimes)
+	var total float64
+	for _, s := range seconds {
+		total += s
+	}
+	r.Migration = &MigrationResult{
+		TrafficGB:            traffic / 1024,
+		Duration:             newDelayStats(durationSeconds(durations)),
+		Downtime:             newDelayStats(seconds),
+		TotalDowntimeSeconds: total,
+		Aborted:              aborted,
+	}
+}
+
+// AddConsolidation records how hosts were drained and powered down over a
+// run of the given duration; saved energy is given in Wh.
+func (r *StrategyResult) AddConsolidation(drained, powerDowns, boots int, offTime time.Duration, hosts int, duration time.Duration, savedWh float64) {
+	r.Consolidation = &ConsolidationResult{
+		HostsDrained:   drained,
+		PowerDowns:     powerDowns,
+		Boots:          boots,
+		EnergySavedKWh: savedWh / 1000,
+	}
+	if hosts > 0 && duration > 0 {
+		r.Consolidation.HostTimeOff = float64(offTime) / float64(duration) / float64(hosts) * 100
+	}
+}
+
+// AddEnergy records the energy the hosts used over the run's duration, in
+// Wh by component and by host, and how much of it the containers were
+// charged. It expects the containers to have been added.
+func (r *StrategyResult) AddEnergy(energy models.PowerBreakdown, hosts map[string]float64, duration time.Duration) {
+	e := &EnergyResult{
+		TotalKWh:    energy.Total() / 1000,
+		PlatformKWh: energy.Platform / 1000,
+		CPUKWh:      energy.CPU / 1000,
+		MemoryKWh:   energy.Memory / 1000,
+		GPUKWh:      energy.GPU / 1000,
+		Hosts:       make(map[string]float64, len(hosts)),
+	}
+	if duration > 0 {
+		e.AvgPowerKW = e.TotalKWh / duration.Hours()
+	}
+	for _, container := range r.ContainerResults {
+		e.ContainerKWh += container.EnergyKWh
+	}
+	if r.CompletedContainers > 0 {
+		e.PerCompletedKWh = e.TotalKWh / float64(r.CompletedContainers)
+	}
+	perHost := make([]float64, 0, len(hosts))
+	for id, wh := range hosts {
+		e.Hosts[id] = wh / 1000
+		perHost = append(perHost, wh/1000)
+	}
+	if len(perHost) > 0 {
+		e.Host = metrics.NewStats(perHost)
+	}
+	r.Energy = e
+}
+
+// energyKWh returns the energy the strategy's run used, or 0 if it was not
+// metered.
+func (r StrategyResult) energyKWh() float64 {
+	if r.Energy == nil {
+		return 0
+	}
+	return r.Energy.TotalKWh
+}
+
+// AddPowerCap records how the power budgets held back placements and GPU
+// clocks. It expects the containers to have been added.
+func (r *StrategyResult) AddPowerCap(refused, throttled int, gpuBusy, gpuThrottled, overBudget time.Duration, peakUse float64) {
+	p := &PowerCapResult{
+		Refused:           refused,
+		Throttled:         throttled,
+		OverBudgetSeconds: overBudget.Seconds(),
+		PeakBudgetUse:     peakUse * 100,
+	}
+	if gpuBusy > 0 {
+		p.GPUTimeThrottled = float64(gpuThrottled) / float64(gpuBusy) * 100
+	}
+	for _, container := range r.ContainerResults {
+		p.ContainerThrottledHours += container.ThrottledSeconds / 3600
+	}
+	r.PowerCap = p
+}
+
+func durationSeconds(durations []time.Duration) []float64 {
+	seconds := make([]float64, len(durations))
+	for i, d := range durations {
+		seconds[i] = d.Seconds()
+	}
+	return seconds
+}
+
+func newDelayStats(delays []float64) DelayStats {
+	if len(delays) == 0 {
+		return DelayStats{}
+	}
+	sorted := make([]float64, len(delays))
+	copy(sorted, delays)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, d := range sorted {
+		sum += d
+	}
+	return DelayStats{
+		AvgSeconds: sum / float64(len(sorted)),
+		P95Seconds: metrics.Percentile(sorted, 95),
+		MaxSeconds: sorted[len(sorted)-1],
+	}
+}
+
+func WriteJSON(w io.Writer, c *Comparison) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+func WriteMarkdown(w io.Writer, c *Comparison) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Strategy comparison: %s\n\n", c.Scenario)
+	fmt.Fprintf(&sb, "Seed `%d`, simulated duration %s.\n\n", c.Seed, c.Duration)
+
+	sb.WriteString("| Strategy | CPU avg % | CPU p95 % | Memory avg % | Memory p95 % | GPU avg % | GPU p95 % | QoS violations | Migrations | Unplaced | Completed | Queue delay avg (s) | Queue delay p95 (s) | Energy (kWh) |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range c.Results {
+		m := r.Metrics
+		fmt.Fprintf(&sb, "| %s | %.2f | %.2f | %.2f | %.2f | %.2f | %.2f | %d | %d | %d/%d | %d | %.0f | %.0f | %.2f |\n",
+			r.Strategy,
+			m.CPU.Avg, m.CPU.P95,
+			m.Memory.Avg, m.Memory.P95,
+			m.GPU.Avg, m.GPU.P95,
+			r.QoSViolations,
+			r.Migrations,
+			r.UnplacedContainers, r.Containers,
+			r.CompletedContainers,
+			r.QueueDelay.AvgSeconds, r.QueueDelay.P95Seconds,
+			r.energyKWh())
+	}
+
+	writeJobGPUCounts(&sb, c.Results)
+	writeGangs(&sb, c.Results)
+	writeMIG(&sb, c.Results)
+	writeSharing(&sb, c.Results)
+	writeIO(&sb, c.Results)
+	writeNetwork(&sb, c.Results)
+	writeMigrations(&sb, c.Results)
+	writeConsolidation(&sb, c.Results)
+	writeEnergy(&sb, c.Results)
+	writePowerCap(&sb, c.Results)
+	writeTopology(&sb, c.Results)
+	writeFailureDomains(&sb, c.Results)
+	writeUnplacedReasons(&sb, c.Results)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeJobGPUCounts renders how many containers started with each GPU count.
+func writeJobGPUCounts(sb *strings.Builder, results []StrategyResult) {
+	sizes := map[int]bool{}
+	for _, r := range results {
+		for size := range r.JobGPUCounts {
+			sizes[size] = true
+		}
+	}
+	if len(sizes) == 0 {
+		return
+	}
+	ordered := make([]int, 0, len(sizes))
+	for size := range sizes {
+		ordered = append(ordered, size)
+	}
+	sort.Ints(ordered)
+
+	sb.WriteString("\n## GPUs per job\n\n| Strategy |")
+	for _, size := range ordered {
+		fmt.Fprintf(sb, " %d GPU |", size)
+	}
+	sb.WriteString(" Multi-host |\n|---|")
+	for range ordered {
+		sb.WriteString("---:|")
+	}
+	sb.WriteString("---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "| %s |", r.Strategy)
+		for _, size := range ordered {
+			fmt.Fprintf(sb, " %d |", r.JobGPUCounts[size])
+		}
+		fmt.Fprintf(sb, " %d |\n", r.DistributedJobs)
+	}
+}
+
+// writeGangs renders gang scheduling outcomes, if any gang jobs were run.
+func writeGangs(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.GangsStarted > 0 || r.GangsWaiting > 0 || r.GangsTimedOut > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Gang scheduling\n\n")
+	sb.WriteString("| Strategy | Gangs started | Gangs still waiting | Gangs timed out | Wait avg (s) | Wait p95 (s) | Wait max (s) |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "| %s | %d | %d | %d | %.0f | %.0f | %.0f |\n",
+			r.Strategy, r.GangsStarted, r.GangsWaiting, r.GangsTimedOut,
+			r.GangWait.AvgSeconds, r.GangWait.P95Seconds, r.GangWait.MaxSeconds)
+	}
+}
+
+// writeMIG renders MIG partitioning outcomes, if any MIG GPUs were used.
+func writeMIG(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Reconfigurations > 0 || r.Metrics.MIGFragmentation.P95 > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## MIG partitions\n\n")
+	sb.WriteString("| Strategy | Reconfigurations | Fragmentation avg % | Fragmentation p95 % |\n")
+	sb.WriteString("|---|---:|---:|---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "| %s | %d | %.2f | %.2f |\n",
+			r.Strategy, r.Reconfigurations,
+			r.Metrics.MIGFragmentation.Avg, r.Metrics.MIGFragmentation.P95)
+	}
+}
+
+// writeSharing renders how much contention for shared GPUs and I/O slowed
+// containers down, if it slowed any down at all.
+func writeSharing(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Slowdown.P95 > 1 || r.SlowdownViolations > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Contention slowdown\n\n")
+	sb.WriteString("| Strategy | Slowdown avg | Slowdown p95 | Containers over QoS slowdown |\n")
+	sb.WriteString("|---|---:|---:|---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "| %s | %.2fx | %.2fx | %d |\n",
+			r.Strategy, r.Slowdown.Avg, r.Slowdown.P95, r.SlowdownViolations)
+	}
+}
+
+// writeIO renders I/O utilization and throttling, if any host's I/O was
+// busy.
+func writeIO(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Metrics.IO.P95 > 0 || r.IOThrottled > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## I/O\n\n")
+	sb.WriteString("| Strategy | I/O avg % | I/O p95 % | Containers throttled by I/O |\n")
+	sb.WriteString("|---|---:|---:|---:|\n")
+	for _, r := range results {
+		fmt.Fprintf(sb, "| %s | %.2f | %.2f | %d |\n",
+			r.Strategy, r.Metrics.IO.Avg, r.Metrics.IO.P95, r.IOThrottled)
+	}
+}
+
+// writeNetwork renders the traffic between hosts, if the network was
+// modelled.
+func writeNetwork(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Network != nil {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Network\n\n")
+	sb.WriteString("| Strategy | Migration traffic (GB) | Migration transfer avg (s) | Dataset traffic (GB) | Dataset wait avg (s) | Dataset wait p95 (s) | Containers throttled by congestion |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		n := r.Network
+		if n == nil {
+			continue
+		}
+		fmt.Fprintf(sb, "| %s | %.1f | %.1f | %.1f | %.1f | %.1f | %d |\n",
+			r.Strategy, n.MigrationTrafficGB, n.MigrationTransfer.AvgSeconds,
+			n.DatasetTrafficGB, n.DatasetWait.AvgSeconds, n.DatasetWait.P95Seconds, n.Throttled)
+	}
+}
+
+// writeMigrations renders what migrations cost and how many the migration
+// limits held back, if migrations were not instant or any were held back.
+func writeMigrations(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Migration != nil || r.CooldownSuppressed > 0 || r.BudgetSuppressed > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Migrations\n\n")
+	sb.WriteString("| Strategy | Migrations | Traffic (GB) | Duration avg (s) | Duration p95 (s) | Downtime total (s) | Downtime avg (s) | Downtime max (s) | Aborted | Suppressed by cooldown | Suppressed by budget |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		m := r.Migration
+		if m == nil {
+			m = &MigrationResult{}
+		}
+		fmt.Fprintf(sb, "| %s | %d | %.1f | %.1f | %.1f | %.1f | %.1f | %.1f | %d | %d | %d |\n",
+			r.Strategy, r.Migrations, m.TrafficGB, m.Duration.AvgSeconds, m.Duration.P95Seconds,
+			m.TotalDowntimeSeconds, m.Downtime.AvgSeconds, m.Downtime.MaxSeconds, m.Aborted,
+			r.CooldownSuppressed, r.BudgetSuppressed)
+	}
+}
+
+// writeConsolidation renders the energy consolidation saved next to what it
+// cost in QoS, if consolidation was on.
+func writeConsolidation(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Consolidation != nil {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Consolidation\n\n")
+	sb.WriteString("| Strategy | Hosts drained | Power-downs | Boots | Host time off % | Energy (kWh) | Energy saved (kWh) | QoS violations | Queue delay avg (s) | Slowdown avg | Migration downtime (s) |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		c := r.Consolidation
+		if c == nil {
+			continue
+		}
+		var downtime float64
+		if r.Migration != nil {
+			downtime = r.Migration.TotalDowntimeSeconds
+		}
+		fmt.Fprintf(sb, "| %s | %d | %d | %d | %.1f | %.2f | %.2f | %d | %.0f | %.2fx | %.1f |\n",
+			r.Strategy, c.HostsDrained, c.PowerDowns, c.Boots, c.HostTimeOff,
+			r.energyKWh(), c.EnergySavedKWh, r.QoSViolations,
+			r.QueueDelay.AvgSeconds, r.Slowdown.Avg, downtime)
+	}
+}
+
+// writeEnergy renders where the hosts' energy went, if it was metered.
+func writeEnergy(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.Energy != nil && r.Energy.TotalKWh > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Energy\n\n")
+	sb.WriteString("| Strategy | Energy (kWh) | Platform (kWh) | CPU (kWh) | Memory (kWh) | GPU (kWh) | Avg power (kW) | Containers (kWh) | Unattributed % | Per completed container (kWh) | Host avg (kWh) | Host p95 (kWh) |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		e := r.Energy
+		if e == nil {
+			continue
+		}
+		var unattributed float64
+		if e.TotalKWh > 0 {
+			unattributed = max(e.TotalKWh-e.ContainerKWh, 0) / e.TotalKWh * 100
+		}
+		fmt.Fprintf(sb, "| %s | %.2f | %.2f | %.2f | %.2f | %.2f | %.2f | %.2f | %.1f | %.3f | %.2f | %.2f |\n",
+			r.Strategy, e.TotalKWh, e.PlatformKWh, e.CPUKWh, e.MemoryKWh, e.GPUKWh,
+			e.AvgPowerKW, e.ContainerKWh, unattributed, e.PerCompletedKWh,
+			e.Host.Avg, e.Host.P95)
+	}
+}
+
+// writePowerCap renders what holding to the power budgets cost, if the
+// cluster was power capped.
+func writePowerCap(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if r.PowerCap != nil {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Power cap\n\n")
+	sb.WriteString("| Strategy | Refused placements | Throttled containers | GPU time throttled % | Container time throttled (h) | Time over budget (s) | Peak budget use % | Energy (kWh) | Queue delay avg (s) | Slowdown avg | Slowdown p95 |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range results {
+		p := r.PowerCap
+		if p == nil {
+			continue
+		}
+		fmt.Fprintf(sb, "| %s | %d | %d | %.1f | %.1f | %.0f | %.1f | %.2f | %.0f | %.2fx | %.2fx |\n",
+			r.Strategy, p.Refused, p.Throttled, p.GPUTimeThrottled, p.ContainerThrottledHours,
+			p.OverBudgetSeconds, p.PeakBudgetUse, r.energyKWh(),
+			r.QueueDelay.AvgSeconds, r.Slowdown.Avg, r.Slowdown.P95)
+	}
+}
+
+// writeTopology renders how well connected multi-GPU containers' GPUs were,
+// if any started on a host with a described topology.
+func writeTopology(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if len(r.TopologyLinks) > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## GPU topology\n\n")
+	sb.WriteString("| Strategy | Multi-GPU starts | Suboptimal | Suboptimal %")
+	for _, link := range models.LinkTypes {
+		fmt.Fprintf(sb, " | %s", link)
+	}
+	sb.WriteString(" |\n|---|---:|---:|---:" + strings.Repeat("|---:", len(models.LinkTypes)) + "|\n")
+	for _, r := range results {
+		starts := 0
+		for _, count := range r.TopologyLinks {
+			starts += count
+		}
+		suboptimal := 0.0
+		if starts > 0 {
+			suboptimal = float64(r.SuboptimalTopology) / float64(starts) * 100
+		}
+		fmt.Fprintf(sb, "| %s | %d | %d | %.1f", r.Strategy, starts, r.SuboptimalTopology, suboptimal)
+		for _, link := range models.LinkTypes {
+			fmt.Fprintf(sb, " | %d", r.TopologyLinks[link.String()])
+		}
+		sb.WriteString(" |\n")
+	}
+}
+
+// writeFailureDomains renders how concentrated the placement groups asked to
+// spread were in failure domains, if the hosts were located in the
+// datacenter hierarchy.
+func writeFailureDomains(sb *strings.Builder, results []StrategyResult) {
+	var levels []models.Level
+	for _, level := range models.Levels {
+		for _, r := range results {
+			if _, ok := r.DomainConcentration[level.String()]; ok {
+				levels = append(levels, level)
+				break
+			}
+		}
+	}
+	if len(levels) == 0 || len(levels) == 1 && levels[0] == models.HostLevel {
+		return
+	}
+
+	sb.WriteString("\n## Failure domains\n\n")
+	sb.WriteString("Largest share of a spread placement group's containers started in one domain, averaged over groups.\n\n")
+	sb.WriteString("| Strategy")
+	for _, level := range levels {
+		fmt.Fprintf(sb, " | Per %s %%", level)
+	}
+	sb.WriteString(" |\n|---" + strings.Repeat("|---:", len(levels)) + "|\n")
+	for _, r := range results {
+		sb.WriteString("| " + r.Strategy)
+		for _, level := range levels {
+			fmt.Fprintf(sb, " | %.1f", r.DomainConcentration[level.String()])
+		}
+		sb.WriteString(" |\n")
+	}
+}
+
+// maxReasons caps how many unplaced reasons are listed per strategy.
+const maxReasons = 10
+
+// writeUnplacedReasons renders why containers were left unplaced, most
+// common reasons first.
+func writeUnplacedReasons(sb *strings.Builder, results []StrategyResult) {
+	any := false
+	for _, r := range results {
+		if len(r.UnplacedReasons) > 0 {
+			any = true
+		}
+	}
+	if !any {
+		return
+	}
+
+	sb.WriteString("\n## Unplaced containers\n\n")
+	sb.WriteString("| Strategy | Reason | Containers |\n")
+	sb.WriteString("|---|---|---:|\n")
+	for _, r := range results {
+		reasons := make([]string, 0, len(r.UnplacedReasons))
+		for reason := range r.UnplacedReasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if r.UnplacedReasons[reasons[i]] != r.UnplacedReasons[reasons[j]] {
+				return r.UnplacedReasons[reasons[i]] > r.UnplacedReasons[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		for i, reason := range reasons {
+			if i == maxReasons {
+				fmt.Fprintf(sb, "| %s | %d other reasons | |\n", r.Strategy, len(reasons)-maxReasons)
+				break
+			}
+			fmt.Fprintf(sb, "| %s | %s | %d |\n", r.Strategy, reason, r.UnplacedReasons[reason])
+		}
+	}
+}
+
+// WriteFiles writes the comparison as <base>.md and <base>.json.
+func WriteFiles(base string, c *Comparison) error {
+	writers := map[string]func(io.Writer, *Comparison) error{
+		base + ".md":   WriteMarkdown,
+		base + ".json": WriteJSON,
+	}
+	for path, write := range writers {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := write(file, c); err != nil {
+			file.Close()
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// GPUDevice is a real GPU product with fixed specs, as listed in the GPU
+// catalog. Scenarios refer to devices by name wherever a GPU model is
+// expected.
+type GPUDevice struct {
+	Name                 string     `json:"name"`
+	Architecture         string     `json:"architecture"`
+	ComputeCapability    string     `json:"compute_capability"`
+	CUDACores            int        `json:"cuda_cores"`
+	TensorCores          int        `json:"tensor_cores"`
+	TensorCoreGeneration int        `json:"tensor_core_generation"`
+	VRAM                 int        `json:"vram"`             // in MB
+	MemoryBandwidth      int        `json:"memory_bandwidth"` // in GB/s
+	FP32TFLOPS           float64    `json:"fp32_tflops"`
+	FP16TFLOPS           float64    `json:"fp16_tflops"`          // dense tensor throughput
+	FP8TFLOPS            float64    `json:"fp8_tflops,omitempty"` // zero when FP8 is not supported
+	TDP                  int        `json:"tdp"`                  // in watts
+	IdlePower            int        `json:"idle_power,omitempty"` // in watts
+	MIG                  *MIGConfig `json:"mig,omitempty"`
+
+	// DVFS is the device's clock curve; see GPUModel.DVFS.
+	DVFS []DVFSState `json:"dvfs,omitempty"`
+}
+
+// Catalog is the format of GPU catalog files.
+type Catalog struct {
+	Devices []GPUDevice `json:"devices"`
+}
+
+//go:embed gpus.json
+var builtinCatalog []byte
+
+var builtinDevices = mustParseCatalog(builtinCatalog)
+
+func mustParseCatalog(data []byte) []GPUDevice {
+	var catalog Catalog
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&catalog); err != nil {
+		panic(fmt.Sprintf("built-in GPU catalog: %v", err))
+	}
+	return catalog.Devices
+}
+
+// BuiltinDevices returns the devices of the built-in GPU catalog.
+func BuiltinDevices() []GPUDevice {
+	return append([]GPUDevice(nil), builtinDevices...)
+}
+
+// LoadCatalog reads a GPU catalog file.
+func LoadCatalog(path string) ([]GPUDevice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var catalog Catalog
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, describeDecodeError(data, err))
+	}
+	return catalog.Devices, nil
+}
+
+// model describes the device as a GPU model whose ranges hold a single value.
+func (d GPUDevice) model() GPUModel {
+	return GPUModel{
+		Name:                 d.Name,
+		CUDACores:            IntRange{Min: d.CUDACores, Max: d.CUDACores},
+		TensorCores:          IntRange{Min: d.TensorCores, Max: d.TensorCores},
+		VRAM:                 IntRange{Min: d.VRAM, Max: d.VRAM},
+		MemoryBandwidth:      IntRange{Min: d.MemoryBandwidth, Max: d.MemoryBandwidth},
+		TFLOPS:               FloatRange{Min: d.FP32TFLOPS, Max: d.FP32TFLOPS},
+		PowerConsumption:     IntRange{Min: d.TDP, Max: d.TDP},
+		IdlePower:            IntRange{Min: d.IdlePower, Max: d.IdlePower},
+		MIG:                  d.MIG,
+		DVFS:                 d.DVFS,
+		Architecture:         d.Architecture,
+		ComputeCapability:    d.ComputeCapability,
+		TensorCoreGeneration: d.TensorCoreGeneration,
+		FP16TFLOPS:           d.FP16TFLOPS,
+		FP8TFLOPS:            d.FP8TFLOPS,
+	}
+}
+
+var gpuSpec = regexp.MustCompile(`^\s*(\d+)\s*x\s*(\S.*?)\s*$`)
+
+// parseGPUSpec splits a host's GPU declaration such as "8x H100" into the
+// number of GPUs and the model name.
+func parseGPUSpec(spec string) (count int, model string, err error) {
+	match := gpuSpec.FindStringSubmatch(spec)
+	if match == nil {
+		return 0, "", fmt.Errorf("invalid GPU spec %q, want e.g. \"8x H100\"", spec)
+	}
+	count, err = strconv.Atoi(match[1])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid GPU count in %q: %w", spec, err)
+	}
+	return count, match[2], nil
+}
@@ -0,0 +1,358 @@
+package scenario
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/broker"
+	"gpu-cloudsim/pkg/network"
+	"gpu-cloudsim/pkg/orchestrator"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Generate builds the hosts (with their GPUs attached) and the containers
+// described by the scenario. All randomness is drawn from rng, so the same
+// seed always yields the same cluster.
+func (s *Scenario) Generate(rng *rand.Rand) *models.Cluster {
+	var hosts []*models.Host
+	gpuCount := 0
+	for _, class := range s.HostClasses {
+		modelName, gpusPerHost := class.gpus()
+		model, _ := s.GPUModel(modelName)
+		sharing := class.Sharing.gpuSharing()
+		for i := 0; i < class.Count; i++ {
+			host := models.NewHost(fmt.Sprintf("host-%d", len(hosts)+1), class.CPUCores.Sample(rng), class.Memory.Sample(rng))
+			for j := 0; j < gpusPerHost; j++ {
+				gpuCount++
+				gpu := model.newGPU(fmt.Sprintf("gpu-%d", gpuCount), rng)
+				if class.MIG {
+					gpu.MIG = model.MIG.layout()
+				}
+				gpu.Sharing = sharing
+				if len(class.GPULabels) > 0 {
+					gpu.Labels = make(map[string]string, len(class.GPULabels))
+					for k, v := range class.GPULabels {
+						gpu.Labels[k] = v
+					}
+				}
+				host.AddGPU(gpu)
+			}
+			host.Topology = class.Topology.build(host.GPUs)
+			host.Location = class.Location.place(i)
+			host.DiskBandwidth = class.DiskBandwidth.Sample(rng)
+			host.NICBandwidth = class.NICBandwidth.Sample(rng)
+			host.BasePower = class.BasePower.Sample(rng)
+			host.CPUIdlePower = class.CPUIdlePower.Sample(rng)
+			host.CPUMaxPower = max(class.CPUMaxPower.Sample(rng), host.CPUIdlePower)
+			host.MemoryPower = class.MemoryPower
+			hosts = append(hosts, host)
+		}
+	}
+
+	var containers []*models.Container
+	var jobs []*models.Job
+	for _, workload := range s.Workloads {
+		model, _ := s.GPUModel(workload.GPUModel)
+		var arrival time.Duration
+		for i := 0; i < workload.Count; i++ {
+			id := fmt.Sprintf("container-%d", len(containers)+1)
+			container := models.NewContainer(id,
+				workload.CPURequest.Sample(rng),
+				workload.MemoryRequest.Sample(rng),
+				workload.gpuRequirement(model, rng),
+				workload.Priority.Sample(rng))
+
+			if workload.ArrivalInterval > 0 {
+				arrival += time.Duration(rng.ExpFloat64() * float64(workload.ArrivalInterval))
+			}
+			container.SubmitTime = arrival
+			container.ExpectedRuntime = workload.Runtime.Sample(rng)
+			container.Group = workload.Name
+			container.DiskIO = workload.DiskIO.Sample(rng)
+			container.NetworkIO = workload.NetworkIO.Sample(rng)
+			container.DatasetSize = workload.DatasetSize.Sample(rng)
+			container.SyncTraffic = workload.SyncTraffic.Sample(rng)
+			container.Placement = workload.placement()
+
+			if workload.GangSize <= 1 {
+				containers = append(containers, container)
+				continue
+			}
+
+			// Gang members are identical workers of one job
+			members := make([]*models.Container, workload.GangSize)
+			for k := range members {
+				member := container.Clone()
+				member.ID = fmt.Sprintf("container-%d", len(containers)+1)
+				member.Group = fmt.Sprintf("job-%d", len(jobs)+1)
+				members[k] = member
+				containers = append(containers, member)
+			}
+			jobs = append(jobs, models.NewJob(fmt.Sprintf("job-%d", len(jobs)+1), members, true, time.Duration(workload.GangTimeout)))
+		}
+	}
+
+	// Keep containers in submission order so arrivals are easy to follow.
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].SubmitTime < containers[j].SubmitTime
+	})
+
+	cluster := models.NewCluster(hosts, containers)
+	cluster.Jobs = jobs
+	return cluster
+}
+
+func (m GPUModel) newGPU(id string, rng *rand.Rand) *models.GPU {
+	gpu := models.NewGPU(id,
+		m.CUDACores.Sample(rng),
+		m.TensorCores.Sample(rng),
+		m.VRAM.Sample(rng),
+		m.MemoryBandwidth.Sample(rng),
+		m.TFLOPS.Sample(rng),
+		m.PowerConsumption.Sample(rng))
+	gpu.IdlePower = m.IdlePower.Sample(rng)
+	gpu.DVFS = m.dvfs()
+	gpu.Model = m.Name
+	gpu.Architecture = m.Architecture
+	gpu.ComputeCapability = m.ComputeCapability
+	gpu.TensorCoreGeneration = m.TensorCoreGeneration
+	gpu.FP16TFLOPS = m.FP16TFLOPS
+	gpu.FP8TFLOPS = m.FP8TFLOPS
+	return gpu
+}
+
+// dvfs converts the model's clock curve; nil leaves the default curve.
+func (m GPUModel) dvfs() []models.DVFSState {
+	if len(m.DVFS) == 0 {
+		return nil
+	}
+	states := make([]models.DVFSState, len(m.DVFS))
+	for i, state := range m.DVFS {
+		states[i] = models.DVFSState{Clock: state.Clock, Performance: state.Performance, Power: state.Power}
+	}
+	return states
+}
+
+// gpuSharing converts the sharing configuration; nil leaves GPUs unshared.
+func (s *Sharing) gpuSharing() *models.GPUSharing {
+	if s == nil {
+		return nil
+	}
+	mode, _ := models.ParseSharingMode(s.Mode)
+	return &models.GPUSharing{
+		Mode:                mode,
+		MaxTenants:          s.MaxTenants,
+		TenantOverhead:      s.TenantOverhead,
+		BandwidthContention: s.BandwidthContention,
+	}
+}
+
+// topologyPresets lists the known topology presets.
+var topologyPresets = []string{"nvswitch", "hybrid-cube-mesh", "nvlink-pairs", "pcie"}
+
+// expand fills in the preset's layout for n GPUs, keeping any field set
+// explicitly. Unknown presets are left for Validate to report.
+func (t Topology) expand(n int) Topology {
+	if t.Preset == "" {
+		return t
+	}
+	if t.NUMANodes == nil {
+		half := (n + 1) / 2
+		t.NUMANodes = [][]int{indexRange(0, half), indexRange(half, n)}
+	}
+	if t.PCIeSwitches == nil {
+		for i := 0; i < n; i += 2 {
+			t.PCIeSwitches = append(t.PCIeSwitches, indexRange(i, min(i+2, n)))
+		}
+	}
+	if t.NVLinks == nil {
+		switch t.Preset {
+		case "nvlink-pairs":
+			for i := 0; i+1 < n; i += 2 {
+				t.NVLinks = append(t.NVLinks, [2]int{i, i + 1})
+			}
+		case "hybrid-cube-mesh":
+			for _, quad := range [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}} {
+				for i, a := range quad {
+					for _, b := range quad[i+1:] {
+						t.NVLinks = append(t.NVLinks, [2]int{a, b})
+					}
+				}
+			}
+			for i := 0; i < 4; i++ {
+				t.NVLinks = append(t.NVLinks, [2]int{i, i + 4})
+			}
+		}
+	}
+	if t.Preset == "nvswitch" {
+		t.NVSwitch = true
+	}
+	return t
+}
+
+func indexRange(from, to int) []int {
+	indexes := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// build wires up the topology of one host's GPUs; nil leaves it flat. GPUs
+// not listed under any NUMA node or PCIe switch each get one of their own.
+func (t *Topology) build(gpus []*models.GPU) *models.Topology {
+	if t == nil {
+		return nil
+	}
+	spec := t.expand(len(gpus))
+	topology := models.NewTopology()
+	topology.NVSwitch = spec.NVSwitch
+	for i, gpu := range gpus {
+		topology.NUMANode[gpu.ID] = -1 - i
+		topology.PCIeSwitch[gpu.ID] = -1 - i
+	}
+	for node, indexes := range spec.NUMANodes {
+		for _, i := range indexes {
+			topology.NUMANode[gpus[i].ID] = node
+		}
+	}
+	for sw, indexes := range spec.PCIeSwitches {
+		for _, i := range indexes {
+			topology.PCIeSwitch[gpus[i].ID] = sw
+		}
+	}
+	for _, link := range spec.NVLinks {
+		topology.AddNVLink(gpus[link[0]].ID, gpus[link[1]].ID)
+	}
+	return topology
+}
+
+// place locates the class's i-th host; hosts of a class without a location
+// all share the empty one.
+func (l *Location) place(i int) models.Location {
+	if l == nil {
+		return models.Location{}
+	}
+	zone := l.Zones[i%len(l.Zones)]
+	rack := 0
+	if l.HostsPerRack > 0 {
+		rack = i / len(l.Zones) / l.HostsPerRack
+	}
+	return models.Location{Region: l.Region, Zone: zone, Rack: fmt.Sprintf("rack-%d", rack+1)}
+}
+
+func (w WorkloadGenerator) placement() []models.PlacementRule {
+	if len(w.Placement) == 0 {
+		return nil
+	}
+	rules := make([]models.PlacementRule, len(w.Placement))
+	for i, rule := range w.Placement {
+		level, _ := models.ParseLevel(rule.Level) // checked by Validate
+		policy, _ := models.ParsePlacementPolicy(rule.Policy)
+		rules[i] = models.PlacementRule{Level: level, Policy: policy, Required: rule.Required}
+	}
+	return rules
+}
+
+// Config converts the network description.
+func (n *Network) Config() network.Config {
+	return network.Config{
+		RackOversubscription:  n.RackOversubscription,
+		SpineOversubscription: n.SpineOversubscription,
+		StorageBandwidth:      n.StorageBandwidth,
+		RackLatency:           time.Duration(n.Latency.Rack),
+		ZoneLatency:           time.Duration(n.Latency.Zone),
+		RegionLatency:         time.Duration(n.Latency.Region),
+		InterRegionLatency:    time.Duration(n.Latency.InterRegion),
+	}
+}
+
+// Config converts the migration cost description.
+func (m *Migration) Config() *orchestrator.MigrationConfig {
+	mode := orchestrator.StopAndCopy
+	if m.Mode != "" {
+		mode, _ = orchestrator.ParseMigrationMode(m.Mode) // checked by Validate
+	}
+	return &orchestrator.MigrationConfig{Mode: mode, DirtyFraction: m.DirtyFraction, Bandwidth: m.Bandwidth}
+}
+
+// Config converts the migration limits.
+func (l *MigrationLimits) Config() *orchestrator.MigrationLimits {
+	return &orchestrator.MigrationLimits{
+		TriggerLoad: l.TriggerLoad,
+		TargetLoad:  l.TargetLoad,
+		Cooldown:    time.Duration(l.Cooldown),
+		Budget:      l.Budget,
+		Window:      time.Duration(l.Window),
+	}
+}
+
+// Config converts the consolidation description.
+func (c *Consolidation) Config() *orchestrator.ConsolidationConfig {
+	return &orchestrator.ConsolidationConfig{
+		Interval:  time.Duration(c.Interval),
+		DrainLoad: c.DrainLoad,
+		BootTime:  time.Duration(c.BootTime),
+	}
+}
+
+// Config converts the power cap.
+func (p *PowerCap) Config() *broker.PowerCap {
+	mode := broker.Refuse
+	if p.Mode != "" {
+		mode, _ = broker.ParsePowerCapMode(p.Mode) // checked by Validate
+	}
+	return &broker.PowerCap{Mode: mode, RackBudget: float64(p.RackBudget), ClusterBudget: float64(p.ClusterBudget)}
+}
+
+// layout builds an unpartitioned MIG layout for one GPU.
+func (c *MIGConfig) layout() *models.MIGLayout {
+	profiles := make([]models.MIGProfile, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		profiles[i] = models.MIGProfile{
+			Name:   profile.Name,
+			Slices: profile.Slices,
+			Memory: profile.Memory,
+			Starts: append([]int(nil), profile.Starts...),
+		}
+	}
+	return models.NewMIGLayout(c.Slices, profiles, time.Duration(c.ReconfigurationDelay))
+}
+
+func (w WorkloadGenerator) gpuRequirement(model GPUModel, rng *rand.Rand) models.GPURequirement {
+	if w.GPUModel == "" {
+		return models.GPURequirement{}
+	}
+	constraint, _ := models.ParseConstraint(w.GPUConstraint) // checked by Validate
+	if w.MIGProfile != "" {
+		return models.GPURequirement{
+			Count:      1,
+			MinTFLOPS:  w.MinTFLOPS,
+			Models:     append([]string(nil), w.AllowedGPUModels...),
+			Profile:    w.MIGProfile,
+			Constraint: constraint,
+		}
+	}
+	count := w.GPUCount
+	if count == 0 {
+		count = 1
+	}
+	fraction := w.GPUFraction
+	if fraction == 0 {
+		fraction = 1
+	}
+	scale := func(v int) int {
+		return max(int(float64(v)*fraction), 1)
+	}
+	return models.GPURequirement{
+		Count:           count,
+		CUDACores:       scale(model.CUDACores.Sample(rng)),
+		VRAM:            scale(model.VRAM.Sample(rng)),
+		MemoryBandwidth: scale(model.MemoryBandwidth.Sample(rng)),
+		MinTFLOPS:       w.MinTFLOPS,
+		Models:          append([]string(nil), w.AllowedGPUModels...),
+		MultiHost:       w.MultiHost,
+		Constraint:      constraint,
+	}
+}
@@ -0,0 +1,437 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Scenario describes a complete simulation setup: the cluster to build, the
+// workloads to run on it, the QoS thresholds to enforce and the strategies to
+// compare.
+type Scenario struct {
+	Name string `json:"name"`
+	// Seed seeds the run unless the command line overrides it; without it
+	// a seed is picked from the clock. Zero is a seed like any other.
+	Seed                   *int64   `json:"seed,omitempty"`
+	Duration               Duration `json:"duration"`
+	WorkloadChangeInterval Duration `json:"workload_change_interval"`
+	Strategies             []string `json:"strategies"`
+	// RebalancePolicies lists the policies that migrate containers off
+	// overloaded hosts. Every strategy runs once with each policy; without
+	// any, strategies run once with the threshold policy.
+	RebalancePolicies []string `json:"rebalance_policies,omitempty"`
+
+	// GPUCatalog names a catalog file, relative to the scenario file, whose
+	// devices extend the built-in GPU catalog. Load merges them into
+	// GPUDevices so that run manifests are self-contained.
+	GPUCatalog string      `json:"gpu_catalog,omitempty"`
+	GPUDevices []GPUDevice `json:"gpu_devices,omitempty"`
+
+	QoS         QoSThresholds       `json:"qos"`
+	GPUModels   []GPUModel          `json:"gpu_models,omitempty"`
+	HostClasses []HostClass         `json:"host_classes"`
+	Workloads   []WorkloadGenerator `json:"workloads"`
+	// Network models the traffic between hosts; without it traffic is free.
+	Network *Network `json:"network,omitempty"`
+	// Migration makes migrations copy container state over time; without
+	// it, and without a network, migrations are instant.
+	Migration *Migration `json:"migration,omitempty"`
+	// MigrationLimits damp rebalancing; without them every QoS violation
+	// may migrate any container.
+	MigrationLimits *MigrationLimits `json:"migration_limits,omitempty"`
+	// Consolidation drains lightly loaded hosts and powers down idle ones;
+	// without it every host stays on.
+	Consolidation *Consolidation `json:"consolidation,omitempty"`
+	// PowerCap holds racks and the cluster to power budgets; without it
+	// their draw is unlimited.
+	PowerCap *PowerCap `json:"power_cap,omitempty"`
+}
+
+// PowerCap sets the power budgets of each rack and of the whole cluster in
+// watts; see broker.PowerCap. Mode is refuse, the default, or throttle.
+type PowerCap struct {
+	Mode          string `json:"mode,omitempty"`
+	RackBudget    int    `json:"rack_budget,omitempty"`
+	ClusterBudget int    `json:"cluster_budget,omitempty"`
+}
+
+// Consolidation describes how hosts are drained and powered down; see
+// orchestrator.ConsolidationConfig. DrainLoad is a fraction of a host's
+// capacity.
+type Consolidation struct {
+	Interval  Duration `json:"interval"`
+	DrainLoad float64  `json:"drain_load"`
+	BootTime  Duration `json:"boot_time"`
+}
+
+// MigrationLimits describes the hysteresis, cooldown and rate limit on
+// rebalancing; see orchestrator.MigrationLimits. Loads are fractions of a
+// host's capacity.
+type MigrationLimits struct {
+	TriggerLoad float64  `json:"trigger_load,omitempty"`
+	TargetLoad  float64  `json:"target_load,omitempty"`
+	Cooldown    Duration `json:"cooldown,omitempty"`
+	Budget      int      `json:"budget,omitempty"`
+	Window      Duration `json:"window,omitempty"`
+}
+
+// Migration describes what migrating a container costs; see
+// orchestrator.MigrationConfig. Mode is stop-and-copy (the default) or
+// pre-copy.
+type Migration struct {
+	Mode          string  `json:"mode,omitempty"`
+	DirtyFraction float64 `json:"dirty_fraction,omitempty"`
+	Bandwidth     float64 `json:"bandwidth,omitempty"` // in MB/s
+}
+
+// Network describes the datacenter network; see network.Config. Host NIC
+// bandwidths come from the host classes.
+type Network struct {
+	RackOversubscription  float64 `json:"rack_oversubscription,omitempty"`
+	SpineOversubscription float64 `json:"spine_oversubscription,omitempty"`
+	StorageBandwidth      float64 `json:"storage_bandwidth,omitempty"` // in MB/s
+	Latency               Latency `json:"latency"`
+}
+
+// Latency is the one-way latency between hosts at each distance.
+type Latency struct {
+	Rack        Duration `json:"rack"`
+	Zone        Duration `json:"zone"`
+	Region      Duration `json:"region"`
+	InterRegion Duration `json:"inter_region"`
+}
+
+// QoSThresholds are cluster-average usage limits in percent, plus an
+// optional per-container limit on the slowdown caused by contention for
+// shared GPUs and I/O.
+type QoSThresholds struct {
+	CPU         float64 `json:"cpu"`
+	Memory      float64 `json:"memory"`
+	GPU         float64 `json:"gpu"`
+	IO          float64 `json:"io"`
+	MaxSlowdown float64 `json:"max_slowdown,omitempty"`
+}
+
+// GPUModel describes the range of specs GPUs of this model are drawn from.
+// Catalog devices are GPU models whose ranges hold a single value.
+type GPUModel struct {
+	Name             string     `json:"name"`
+	CUDACores        IntRange   `json:"cuda_cores"`
+	TensorCores      IntRange   `json:"tensor_cores"`
+	VRAM             IntRange   `json:"vram"`             // in MB
+	MemoryBandwidth  IntRange   `json:"memory_bandwidth"` // in GB/s
+	TFLOPS           FloatRange `json:"tflops"`
+	PowerConsumption IntRange   `json:"power_consumption"`    // in watts, at full utilization
+	IdlePower        IntRange   `json:"idle_power,omitempty"` // in watts, with nothing running
+	MIG              *MIGConfig `json:"mig,omitempty"`
+	// DVFS is the model's clock curve, fastest state first; without it the
+	// GPUs follow models.DefaultDVFS when a power cap throttles them.
+	DVFS []DVFSState `json:"dvfs,omitempty"`
+
+	Architecture         string  `json:"architecture,omitempty"`
+	ComputeCapability    string  `json:"compute_capability,omitempty"`
+	TensorCoreGeneration int     `json:"tensor_core_generation,omitempty"`
+	FP16TFLOPS           float64 `json:"fp16_tflops,omitempty"`
+	FP8TFLOPS            float64 `json:"fp8_tflops,omitempty"`
+}
+
+// MIGConfig describes how GPUs of a model can be partitioned when MIG is
+// enabled on them.
+type MIGConfig struct {
+	Slices               int          `json:"slices"` // compute slices per GPU
+	ReconfigurationDelay Duration     `json:"reconfiguration_delay"`
+	Profiles             []MIGProfile `json:"profiles"`
+}
+
+// MIGProfile is a partition shape: Slices consecutive compute slices with
+// Memory MB of VRAM, starting at one of the slice offsets in Starts.
+type MIGProfile struct {
+	Name   string `json:"name"`
+	Slices int    `json:"slices"`
+	Memory int    `json:"memory"` // in MB
+	Starts []int  `json:"starts"`
+}
+
+// DVFSState is one point of a GPU model's clock curve; see
+// models.DVFSState. All three are shares of their full-clock values.
+type DVFSState struct {
+	Clock       float64 `json:"clock"`
+	Performance float64 `json:"performance"`
+	Power       float64 `json:"power"`
+}
+
+// HostClass describes Count identical-shaped hosts. Their GPUs are declared
+// either as GPUs, e.g. "8x H100", or as GPUsPerHost GPUs of GPUModel. With
+// MIG set their GPUs are MIG-enabled and only serve partition requests; with
+// Sharing set they are shared by several containers at once. Topology
+// describes how each host's GPUs are interconnected and Location where the
+// hosts sit in the datacenter.
+type HostClass struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	CPUCores IntRange `json:"cpu_cores"`
+	Memory   IntRange `json:"memory"` // in MB
+	// DiskBandwidth and NICBandwidth are I/O capacities in MB/s; unset
+	// leaves that kind of I/O unmodelled.
+	DiskBandwidth IntRange `json:"disk_bandwidth,omitempty"`
+	NICBandwidth  IntRange `json:"nic_bandwidth,omitempty"`
+	// BasePower is the platform's draw in watts while the host is on, on
+	// top of its GPUs; powering a host down saves it. The CPUs draw from
+	// CPUIdlePower to CPUMaxPower watts with their allocation, and memory
+	// MemoryPower watts per GB installed.
+	BasePower    IntRange `json:"base_power,omitempty"`
+	CPUIdlePower IntRange `json:"cpu_idle_power,omitempty"`
+	CPUMaxPower  IntRange `json:"cpu_max_power,omitempty"`
+	MemoryPower  float64  `json:"memory_power,omitempty"`
+	GPUs         string   `json:"gpus,omitempty"`
+	GPUModel     string   `json:"gpu_model,omitempty"`
+	GPUsPerHost  int      `json:"gpus_per_host,omitempty"`
+	MIG          bool     `json:"mig,omitempty"`
+	Sharing      *Sharing `json:"sharing,omitempty"`
+	// GPULabels are attached to every GPU of the class, e.g. the installed
+	// driver version, for containers' GPU constraints to match.
+	GPULabels map[string]string `json:"gpu_labels,omitempty"`
+	Topology  *Topology         `json:"topology,omitempty"`
+	Location  *Location         `json:"location,omitempty"`
+}
+
+// Location spreads a host class over the zones of a region: hosts are
+// dealt out to the zones in turn and fill each zone's racks HostsPerRack at
+// a time (one rack per zone when zero).
+type Location struct {
+	Region       string   `json:"region"`
+	Zones        []string `json:"zones"`
+	HostsPerRack int      `json:"hosts_per_rack,omitempty"`
+}
+
+// PlacementRule spreads or packs the containers of a placement group over
+// the failure domains of a level; see models.PlacementRule.
+type PlacementRule struct {
+	Level    string `json:"level"`  // region, zone, rack or host
+	Policy   string `json:"policy"` // spread or pack
+	Required bool   `json:"required,omitempty"`
+}
+
+// Topology describes the interconnect of a host's GPUs, which are referred
+// to by their index within the host. A preset lays out a common design:
+//
+//   - nvswitch: all GPUs on one NVSwitch fabric (HGX/DGX A100 and H100)
+//   - hybrid-cube-mesh: two fully NVLinked quads joined GPU to GPU (DGX-1)
+//   - nvlink-pairs: NVLink bridges between GPUs 0-1, 2-3, ...
+//   - pcie: no NVLink
+//
+// Every preset puts two GPUs on each PCIe switch and splits the GPUs evenly
+// over two CPU sockets. The explicit fields override the preset's.
+type Topology struct {
+	Preset       string   `json:"preset,omitempty"`
+	NUMANodes    [][]int  `json:"numa_nodes,omitempty"`
+	PCIeSwitches [][]int  `json:"pcie_switches,omitempty"`
+	NVLinks      [][2]int `json:"nvlinks,omitempty"`
+	NVSwitch     bool     `json:"nvswitch,omitempty"`
+}
+
+// Sharing configures time-sliced or MPS GPU sharing and its interference
+// model; see models.GPUSharing.
+type Sharing struct {
+	Mode                string  `json:"mode"` // time-slicing or mps
+	MaxTenants          int     `json:"max_tenants"`
+	TenantOverhead      float64 `json:"tenant_overhead"`
+	BandwidthContention float64 `json:"bandwidth_contention,omitempty"` // mps only
+}
+
+// WorkloadGenerator describes Count containers. Each container's per-GPU
+// request is drawn from the named GPU model's ranges (no GPU is requested
+// when GPUModel is empty), for GPUCount GPUs (one when unset), optionally
+// restricted to AllowedGPUModels and devices of at least MinTFLOPS. With
+// MultiHost set the GPUs may be spread over several hosts. With MIGProfile
+// set each container instead requests one partition of that profile of the
+// GPU model. GPUFraction scales the per-GPU request down for light
+// containers, e.g. inference servers sharing a GPU. GPUConstraint limits
+// placement to GPUs whose attributes match it; see models.Constraint.
+// Containers arrive as a Poisson process with the given mean
+// ArrivalInterval (all at once when it is zero) and run for a duration drawn
+// from Runtime (until the end of the simulation when zero). With GangSize
+// above one, Count gang jobs of GangSize identical containers are generated
+// instead; each job arrives and runs as a unit and fails if it cannot be
+// placed within GangTimeout (never, when zero). Placement rules apply within
+// each gang job, or across all containers of the workload otherwise. With a
+// network, each container first fetches DatasetSize from storage, and
+// multi-host containers and gang jobs exchange SyncTraffic between hosts.
+type WorkloadGenerator struct {
+	Name             string          `json:"name"`
+	Count            int             `json:"count"`
+	CPURequest       IntRange        `json:"cpu_request"`            // in millicores
+	MemoryRequest    IntRange        `json:"memory_request"`         // in MB
+	DiskIO           IntRange        `json:"disk_io,omitempty"`      // in MB/s
+	NetworkIO        IntRange        `json:"network_io,omitempty"`   // in MB/s
+	DatasetSize      IntRange        `json:"dataset_size,omitempty"` // in MB
+	SyncTraffic      IntRange        `json:"sync_traffic,omitempty"` // in MB/s
+	Priority         IntRange        `json:"priority"`
+	GPUModel         string          `json:"gpu_model"`
+	GPUCount         int             `json:"gpu_count,omitempty"`
+	GPUFraction      float64         `json:"gpu_fraction,omitempty"`
+	AllowedGPUModels []string        `json:"allowed_gpu_models,omitempty"`
+	MinTFLOPS        float64         `json:"min_tflops,omitempty"`
+	MultiHost        bool            `json:"multi_host,omitempty"`
+	MIGProfile       string          `json:"mig_profile,omitempty"`
+	GPUConstraint    string          `json:"gpu_constraint,omitempty"`
+	GangSize         int             `json:"gang_size,omitempty"`
+	GangTimeout      Duration        `json:"gang_timeout,omitempty"`
+	Placement        []PlacementRule `json:"placement,omitempty"`
+	ArrivalInterval  Duration        `json:"arrival_interval,omitempty"`
+	Runtime          DurationRange   `json:"runtime"`
+}
+
+// Load reads and validates a scenario file. Unknown fields are rejected so
+// that typos surface as errors instead of silently falling back to zero.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Scenario
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, describeDecodeError(data, err))
+	}
+
+	if s.GPUCatalog != "" {
+		catalogPath := s.GPUCatalog
+		if !filepath.IsAbs(catalogPath) {
+			catalogPath = filepath.Join(filepath.Dir(path), catalogPath)
+		}
+		devices, err := LoadCatalog(catalogPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: gpu_catalog: %w", path, err)
+		}
+		s.GPUDevices = append(s.GPUDevices, devices...)
+		s.GPUCatalog = ""
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: invalid scenario:\n%w", path, err)
+	}
+	return &s, nil
+}
+
+func describeDecodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: field %q must be %s, got %s", line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err
+}
+
+func position(data []byte, offset int64) (line, col int) {
+	line, col = 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// GPUModel returns the GPU model with the given name, looking at the
+// scenario's own models first, then its catalog devices and finally the
+// built-in catalog.
+func (s *Scenario) GPUModel(name string) (GPUModel, bool) {
+	for _, model := range s.GPUModels {
+		if model.Name == name {
+			return model, true
+		}
+	}
+	for _, devices := range [][]GPUDevice{s.GPUDevices, builtinDevices} {
+		for _, device := range devices {
+			if device.Name == name {
+				return device.model(), true
+			}
+		}
+	}
+	return GPUModel{}, false
+}
+
+// gpus returns the GPU model and number of GPUs of each host of the class.
+func (c HostClass) gpus() (model string, count int) {
+	if c.GPUs != "" {
+		count, model, _ = parseGPUSpec(c.GPUs)
+		return model, count
+	}
+	return c.GPUModel, c.GPUsPerHost
+}
+
+// Profile returns the MIG profile with the given name.
+func (c *MIGConfig) Profile(name string) (MIGProfile, bool) {
+	for _, profile := range c.Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return MIGProfile{}, false
+}
+
+// Default returns the cluster and workload that used to be hard-coded in
+// cmd/main.go. The original run lasted 3 minutes with a workload change every
+// 30 seconds of wall-clock time; on virtual time it covers a simulated week
+// with a workload change every 30 minutes instead.
+func Default() *Scenario {
+	return &Scenario{
+		Name:                   "default",
+		Duration:               Duration(7 * 24 * time.Hour),
+		WorkloadChangeInterval: Duration(30 * time.Minute),
+		Strategies:             []string{"BinPacking", "Priority", "RoundRobin"},
+		QoS: QoSThresholds{
+			CPU:    80.0,
+			Memory: 85.0,
+			GPU:    95.0,
+			IO:     75.0,
+		},
+		GPUModels: []GPUModel{
+			{
+				Name:             "generic",
+				CUDACores:        IntRange{Min: 3584, Max: 11776},
+				TensorCores:      IntRange{Min: 224, Max: 736},
+				VRAM:             IntRange{Min: 8192, Max: 57344},
+				MemoryBandwidth:  IntRange{Min: 900, Max: 3300},
+				TFLOPS:           FloatRange{Min: 13.4, Max: 32.1},
+				PowerConsumption: IntRange{Min: 250, Max: 500},
+			},
+		},
+		HostClasses: []HostClass{
+			{
+				Name:        "standard",
+				Count:       100,
+				CPUCores:    IntRange{Min: 32, Max: 160},
+				Memory:      IntRange{Min: 65536, Max: 589824},
+				GPUModel:    "generic",
+				GPUsPerHost: 2,
+			},
+		},
+		Workloads: []WorkloadGenerator{
+			{
+				Name:          "default",
+				Count:         100,
+				CPURequest:    IntRange{Min: 1000, Max: 17000},
+				MemoryRequest: IntRange{Min: 2048, Max: 34816},
+				Priority:      IntRange{Min: 1, Max: 4},
+				GPUModel:      "generic",
+			},
+		},
+	}
+}
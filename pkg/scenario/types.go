@@ -0,0 +1,64 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// IntRange is a half-open interval [Min, Max).
+type IntRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func (r IntRange) Sample(rng *rand.Rand) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + rng.Intn(r.Max-r.Min)
+}
+
+// FloatRange is a half-open interval [Min, Max).
+type FloatRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+func (r FloatRange) Sample(rng *rand.Rand) float64 {
+	return r.Min + rng.Float64()*(r.Max-r.Min)
+}
+
+// Duration is a time.Duration that is encoded as a string such as "30m0s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30m\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DurationRange is a half-open interval [Min, Max) of durations.
+type DurationRange struct {
+	Min Duration `json:"min"`
+	Max Duration `json:"max"`
+}
+
+func (r DurationRange) Sample(rng *rand.Rand) time.Duration {
+	if r.Max <= r.Min {
+		return time.Duration(r.Min)
+	}
+	return time.Duration(r.Min) + time.Duration(rng.Int63n(int64(r.Max-r.Min)))
+}
@@ -0,0 +1,604 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"gpu-cloudsim/models"
+	"gpu-cloudsim/pkg/broker"
+	"gpu-cloudsim/pkg/orchestrator"
+	"gpu-cloudsim/pkg/scheduler"
+	"slices"
+	"time"
+)
+
+// Validate checks the scenario against its schema and returns every problem
+// found, each prefixed with the path of the offending field.
+func (s *Scenario) Validate() error {
+	v := &validator{}
+
+	if s.Duration <= 0 {
+		v.addf("duration", "must be positive")
+	}
+	if s.WorkloadChangeInterval <= 0 {
+		v.addf("workload_change_interval", "must be positive")
+	}
+
+	if len(s.Strategies) == 0 {
+		v.addf("strategies", "at least one strategy is required")
+	}
+	seenStrategies := map[string]bool{}
+	for i, name := range s.Strategies {
+		path := fmt.Sprintf("strategies[%d]", i)
+		if _, err := scheduler.New(name); err != nil {
+			v.addf(path, "%v", err)
+		}
+		if seenStrategies[name] {
+			v.addf(path, "duplicate strategy %q", name)
+		}
+		seenStrategies[name] = true
+	}
+	seenPolicies := map[string]bool{}
+	for i, name := range s.RebalancePolicies {
+		path := fmt.Sprintf("rebalance_policies[%d]", i)
+		if _, err := orchestrator.NewRebalancePolicy(name); err != nil {
+			v.addf(path, "%v", err)
+		}
+		if seenPolicies[name] {
+			v.addf(path, "duplicate rebalance policy %q", name)
+		}
+		seenPolicies[name] = true
+	}
+
+	v.percent("qos.cpu", s.QoS.CPU)
+	v.percent("qos.memory", s.QoS.Memory)
+	v.percent("qos.gpu", s.QoS.GPU)
+	v.percent("qos.io", s.QoS.IO)
+	if s.QoS.MaxSlowdown != 0 && s.QoS.MaxSlowdown < 1 {
+		v.addf("qos.max_slowdown", "must be at least 1, got %g", s.QoS.MaxSlowdown)
+	}
+
+	knownModels := map[string]bool{}
+	for i, model := range s.GPUModels {
+		path := fmt.Sprintf("gpu_models[%d]", i)
+		if model.Name == "" {
+			v.addf(path+".name", "is required")
+		} else if knownModels[model.Name] {
+			v.addf(path+".name", "duplicate GPU model %q", model.Name)
+		}
+		knownModels[model.Name] = true
+		v.intRange(path+".cuda_cores", model.CUDACores, 1)
+		v.intRange(path+".tensor_cores", model.TensorCores, 0)
+		v.intRange(path+".vram", model.VRAM, 1)
+		v.intRange(path+".memory_bandwidth", model.MemoryBandwidth, 1)
+		v.floatRange(path+".tflops", model.TFLOPS)
+		v.intRange(path+".power_consumption", model.PowerConsumption, 0)
+		v.intRange(path+".idle_power", model.IdlePower, 0)
+		if model.IdlePower.Max > model.PowerConsumption.Min {
+			v.addf(path+".idle_power.max", "must not exceed power_consumption.min (%d), got %d", model.PowerConsumption.Min, model.IdlePower.Max)
+		}
+		if model.MIG != nil {
+			v.mig(path+".mig", model.MIG)
+		}
+		v.dvfs(path+".dvfs", model.DVFS)
+	}
+
+	devices := map[string]bool{}
+	for i, device := range s.GPUDevices {
+		path := fmt.Sprintf("gpu_devices[%d]", i)
+		if device.Name == "" {
+			v.addf(path+".name", "is required")
+		} else if devices[device.Name] {
+			v.addf(path+".name", "duplicate GPU device %q", device.Name)
+		}
+		devices[device.Name] = true
+		v.device(path, device)
+	}
+	for name := range devices {
+		knownModels[name] = true
+	}
+	for _, device := range builtinDevices {
+		knownModels[device.Name] = true
+	}
+	attributes := map[string]bool{}
+	for _, name := range models.BuiltinAttributes {
+		attributes[name] = true
+	}
+	for _, class := range s.HostClasses {
+		for name := range class.GPULabels {
+			attributes[name] = true
+		}
+	}
+	migConfig := func(name string) *MIGConfig {
+		model, _ := s.GPUModel(name)
+		return model.MIG
+	}
+
+	if len(s.HostClasses) == 0 {
+		v.addf("host_classes", "at least one host class is required")
+	}
+	for i, class := range s.HostClasses {
+		path := fmt.Sprintf("host_classes[%d]", i)
+		if class.Name == "" {
+			v.addf(path+".name", "is required")
+		}
+		if class.Count <= 0 {
+			v.addf(path+".count", "must be positive")
+		}
+		v.intRange(path+".cpu_cores", class.CPUCores, 1)
+		v.intRange(path+".memory", class.Memory, 1)
+		v.intRange(path+".disk_bandwidth", class.DiskBandwidth, 0)
+		v.intRange(path+".nic_bandwidth", class.NICBandwidth, 0)
+		v.intRange(path+".base_power", class.BasePower, 0)
+		v.intRange(path+".cpu_idle_power", class.CPUIdlePower, 0)
+		v.intRange(path+".cpu_max_power", class.CPUMaxPower, 0)
+		if class.CPUMaxPower.Max > 0 && class.CPUMaxPower.Min < class.CPUIdlePower.Max {
+			v.addf(path+".cpu_max_power.min", "must not be less than cpu_idle_power.max (%d), got %d", class.CPUIdlePower.Max, class.CPUMaxPower.Min)
+		}
+		if class.MemoryPower < 0 {
+			v.addf(path+".memory_power", "must not be negative, got %g", class.MemoryPower)
+		}
+		modelPath := path + ".gpu_model"
+		if class.GPUs != "" {
+			modelPath = path + ".gpus"
+			if _, _, err := parseGPUSpec(class.GPUs); err != nil {
+				v.addf(modelPath, "%v", err)
+			}
+			if class.GPUModel != "" || class.GPUsPerHost != 0 {
+				v.addf(modelPath, "cannot be combined with gpu_model or gpus_per_host")
+			}
+		}
+		if class.GPUsPerHost < 0 {
+			v.addf(path+".gpus_per_host", "must not be negative")
+		}
+		modelName, gpusPerHost := class.gpus()
+		if gpusPerHost > 0 && !knownModels[modelName] {
+			v.addf(modelPath, "unknown GPU model %q", modelName)
+		}
+		if class.MIG && migConfig(modelName) == nil {
+			v.addf(path+".mig", "GPU model %q has no MIG configuration", modelName)
+		}
+		for name := range class.GPULabels {
+			if slices.Contains(models.BuiltinAttributes, name) {
+				v.addf(path+".gpu_labels", "label %q shadows a built-in GPU attribute", name)
+			}
+		}
+		if class.Sharing != nil {
+			v.sharing(path+".sharing", class.Sharing)
+			if class.MIG {
+				v.addf(path+".sharing", "cannot be combined with mig")
+			}
+		}
+		if class.Topology != nil {
+			v.topology(path+".topology", class.Topology, gpusPerHost)
+		}
+		if class.Location != nil {
+			v.location(path+".location", class.Location)
+		}
+	}
+
+	// Placement rules above the host level need every host to be located.
+	unlocated := ""
+	for _, class := range s.HostClasses {
+		if class.Location == nil && unlocated == "" {
+			unlocated = class.Name
+		}
+	}
+
+	if s.Network != nil {
+		v.network("network", s.Network)
+	}
+	if s.Migration != nil {
+		v.migration("migration", s.Migration)
+	}
+	if s.MigrationLimits != nil {
+		v.migrationLimits("migration_limits", s.MigrationLimits)
+	}
+	if s.PowerCap != nil {
+		v.powerCap("power_cap", s.PowerCap, s.HostClasses)
+	}
+	if c := s.Consolidation; c != nil {
+		if c.Interval <= 0 {
+			v.addf("consolidation.interval", "must be positive")
+		}
+		if c.DrainLoad <= 0 || c.DrainLoad >= 1 {
+			v.addf("consolidation.drain_load", "must be a load in (0, 1), got %g", c.DrainLoad)
+		}
+		if c.BootTime < 0 {
+			v.addf("consolidation.boot_time", "must not be negative")
+		}
+	}
+
+	if len(s.Workloads) == 0 {
+		v.addf("workloads", "at least one workload generator is required")
+	}
+	for i, workload := range s.Workloads {
+		path := fmt.Sprintf("workloads[%d]", i)
+		if workload.Name == "" {
+			v.addf(path+".name", "is required")
+		}
+		if workload.Count <= 0 {
+			v.addf(path+".count", "must be positive")
+		}
+		v.intRange(path+".cpu_request", workload.CPURequest, 1)
+		v.intRange(path+".memory_request", workload.MemoryRequest, 1)
+		v.intRange(path+".disk_io", workload.DiskIO, 0)
+		v.intRange(path+".network_io", workload.NetworkIO, 0)
+		v.intRange(path+".dataset_size", workload.DatasetSize, 0)
+		v.intRange(path+".sync_traffic", workload.SyncTraffic, 0)
+		if workload.DatasetSize.Max > 0 && s.Network == nil {
+			v.addf(path+".dataset_size", "only applies when the scenario has a network")
+		}
+		if workload.SyncTraffic.Max > 0 {
+			if s.Network == nil {
+				v.addf(path+".sync_traffic", "only applies when the scenario has a network")
+			}
+			if !workload.MultiHost && workload.GangSize <= 1 {
+				v.addf(path+".sync_traffic", "only applies to multi_host workloads or gangs")
+			}
+		}
+		v.intRange(path+".priority", workload.Priority, 0)
+		if workload.GPUModel != "" && !knownModels[workload.GPUModel] {
+			v.addf(path+".gpu_model", "unknown GPU model %q", workload.GPUModel)
+		}
+		if workload.GPUCount < 0 {
+			v.addf(path+".gpu_count", "must not be negative")
+		}
+		for j, name := range workload.AllowedGPUModels {
+			if !knownModels[name] {
+				v.addf(fmt.Sprintf("%s.allowed_gpu_models[%d]", path, j), "unknown GPU model %q", name)
+			}
+		}
+		if workload.GPUFraction < 0 || workload.GPUFraction > 1 {
+			v.addf(path+".gpu_fraction", "must be in (0, 1], got %g", workload.GPUFraction)
+		}
+		if workload.MIGProfile != "" {
+			if config := migConfig(workload.GPUModel); config == nil {
+				v.addf(path+".mig_profile", "GPU model %q has no MIG configuration", workload.GPUModel)
+			} else if _, ok := config.Profile(workload.MIGProfile); !ok {
+				v.addf(path+".mig_profile", "unknown MIG profile %q for GPU model %q", workload.MIGProfile, workload.GPUModel)
+			}
+			if workload.GPUCount > 1 || workload.MultiHost {
+				v.addf(path+".mig_profile", "a MIG partition request takes a single GPU")
+			}
+		}
+		if workload.GPUConstraint != "" {
+			constraint, err := models.ParseConstraint(workload.GPUConstraint)
+			if err != nil {
+				v.addf(path+".gpu_constraint", "%v", err)
+			}
+			for _, name := range constraint.Attributes() {
+				if !attributes[name] {
+					v.addf(path+".gpu_constraint", "unknown GPU attribute %q", name)
+				}
+			}
+			if workload.GPUModel == "" {
+				v.addf(path+".gpu_constraint", "only applies to workloads that request GPUs")
+			}
+		}
+		if workload.MinTFLOPS < 0 {
+			v.addf(path+".min_tflops", "must not be negative")
+		}
+		if workload.ArrivalInterval < 0 {
+			v.addf(path+".arrival_interval", "must not be negative")
+		}
+		v.durationRange(path+".runtime", workload.Runtime)
+		if workload.GangSize < 0 {
+			v.addf(path+".gang_size", "must not be negative")
+		}
+		if workload.GangTimeout < 0 {
+			v.addf(path+".gang_timeout", "must not be negative")
+		}
+		if workload.GangTimeout > 0 && workload.GangSize <= 1 {
+			v.addf(path+".gang_timeout", "only applies when gang_size is above 1")
+		}
+		for j, rule := range workload.Placement {
+			rulePath := fmt.Sprintf("%s.placement[%d]", path, j)
+			level, err := models.ParseLevel(rule.Level)
+			if err != nil {
+				v.addf(rulePath+".level", "%v", err)
+			} else if level != models.HostLevel && unlocated != "" {
+				v.addf(rulePath+".level", "host class %q has no location", unlocated)
+			}
+			if _, err := models.ParsePlacementPolicy(rule.Policy); err != nil {
+				v.addf(rulePath+".policy", "%v", err)
+			}
+		}
+	}
+
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("  %s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) device(path string, device GPUDevice) {
+	positive := map[string]float64{
+		"cuda_cores":       float64(device.CUDACores),
+		"vram":             float64(device.VRAM),
+		"memory_bandwidth": float64(device.MemoryBandwidth),
+		"fp32_tflops":      device.FP32TFLOPS,
+		"tdp":              float64(device.TDP),
+	}
+	for _, field := range []string{"cuda_cores", "vram", "memory_bandwidth", "fp32_tflops", "tdp"} {
+		if positive[field] <= 0 {
+			v.addf(path+"."+field, "must be positive")
+		}
+	}
+	if device.TensorCores < 0 {
+		v.addf(path+".tensor_cores", "must not be negative")
+	}
+	if device.FP16TFLOPS < 0 {
+		v.addf(path+".fp16_tflops", "must not be negative")
+	}
+	if device.FP8TFLOPS < 0 {
+		v.addf(path+".fp8_tflops", "must not be negative")
+	}
+	if device.IdlePower < 0 || device.IdlePower > device.TDP {
+		v.addf(path+".idle_power", "must be between 0 and tdp (%d), got %d", device.TDP, device.IdlePower)
+	}
+	if device.MIG != nil {
+		v.mig(path+".mig", device.MIG)
+	}
+	v.dvfs(path+".dvfs", device.DVFS)
+}
+
+// dvfs checks a clock curve: it starts at full clock, and clock, performance
+// and power fall from state to state.
+func (v *validator) dvfs(path string, states []DVFSState) {
+	for i, state := range states {
+		statePath := fmt.Sprintf("%s[%d]", path, i)
+		if i == 0 {
+			if state.Clock != 1 || state.Performance != 1 || state.Power != 1 {
+				v.addf(statePath, "the first state must be full clock, with clock, performance and power 1")
+			}
+			continue
+		}
+		prev := states[i-1]
+		if state.Clock <= 0 || state.Clock >= prev.Clock {
+			v.addf(statePath+".clock", "must be positive and below the previous state's (%g), got %g", prev.Clock, state.Clock)
+		}
+		if state.Performance <= 0 || state.Performance > prev.Performance {
+			v.addf(statePath+".performance", "must be positive and at most the previous state's (%g), got %g", prev.Performance, state.Performance)
+		}
+		if state.Power < 0 || state.Power > prev.Power {
+			v.addf(statePath+".power", "must not be negative or above the previous state's (%g), got %g", prev.Power, state.Power)
+		}
+	}
+}
+
+func (v *validator) mig(path string, config *MIGConfig) {
+	if config.Slices <= 0 {
+		v.addf(path+".slices", "must be positive")
+	}
+	if config.ReconfigurationDelay < 0 {
+		v.addf(path+".reconfiguration_delay", "must not be negative")
+	}
+	if len(config.Profiles) == 0 {
+		v.addf(path+".profiles", "at least one profile is required")
+	}
+	seen := map[string]bool{}
+	for i, profile := range config.Profiles {
+		profilePath := fmt.Sprintf("%s.profiles[%d]", path, i)
+		if profile.Name == "" {
+			v.addf(profilePath+".name", "is required")
+		} else if seen[profile.Name] {
+			v.addf(profilePath+".name", "duplicate MIG profile %q", profile.Name)
+		}
+		seen[profile.Name] = true
+		if profile.Slices <= 0 || profile.Slices > config.Slices {
+			v.addf(profilePath+".slices", "must be between 1 and %d, got %d", config.Slices, profile.Slices)
+		}
+		if profile.Memory <= 0 {
+			v.addf(profilePath+".memory", "must be positive")
+		}
+		if len(profile.Starts) == 0 {
+			v.addf(profilePath+".starts", "at least one start offset is required")
+		}
+		for j, start := range profile.Starts {
+			if start < 0 || start+profile.Slices > config.Slices {
+				v.addf(fmt.Sprintf("%s.starts[%d]", profilePath, j), "partition at offset %d does not fit in %d slices", start, config.Slices)
+			}
+		}
+	}
+}
+
+func (v *validator) sharing(path string, sharing *Sharing) {
+	if _, err := models.ParseSharingMode(sharing.Mode); err != nil {
+		v.addf(path+".mode", "%v", err)
+	}
+	if sharing.MaxTenants < 2 {
+		v.addf(path+".max_tenants", "must be at least 2, got %d", sharing.MaxTenants)
+	}
+	if sharing.TenantOverhead < 0 {
+		v.addf(path+".tenant_overhead", "must not be negative")
+	}
+	if sharing.BandwidthContention < 0 {
+		v.addf(path+".bandwidth_contention", "must not be negative")
+	}
+	if sharing.BandwidthContention > 0 && sharing.Mode == "time-slicing" {
+		v.addf(path+".bandwidth_contention", "only applies to mps, where tenants run concurrently")
+	}
+}
+
+func (v *validator) topology(path string, topology *Topology, gpus int) {
+	if topology.Preset != "" && !slices.Contains(topologyPresets, topology.Preset) {
+		v.addf(path+".preset", "unknown topology preset %q, want one of %v", topology.Preset, topologyPresets)
+	}
+	if topology.Preset == "hybrid-cube-mesh" && topology.NVLinks == nil && gpus != 8 {
+		v.addf(path+".preset", "hybrid-cube-mesh needs 8 GPUs per host, got %d", gpus)
+	}
+	if gpus == 0 {
+		v.addf(path, "hosts of the class have no GPUs")
+	}
+	groups := func(field string, groups [][]int) {
+		seen := map[int]bool{}
+		for i, group := range groups {
+			for j, index := range group {
+				if index < 0 || index >= gpus {
+					v.addf(fmt.Sprintf("%s.%s[%d][%d]", path, field, i, j), "GPU index %d out of range for %d GPUs", index, gpus)
+				} else if seen[index] {
+					v.addf(fmt.Sprintf("%s.%s[%d][%d]", path, field, i, j), "GPU %d is listed twice", index)
+				}
+				seen[index] = true
+			}
+		}
+	}
+	groups("numa_nodes", topology.NUMANodes)
+	groups("pcie_switches", topology.PCIeSwitches)
+	for i, link := range topology.NVLinks {
+		for _, index := range link {
+			if index < 0 || index >= gpus {
+				v.addf(fmt.Sprintf("%s.nvlinks[%d]", path, i), "GPU index %d out of range for %d GPUs", index, gpus)
+			}
+		}
+		if link[0] == link[1] {
+			v.addf(fmt.Sprintf("%s.nvlinks[%d]", path, i), "links GPU %d to itself", link[0])
+		}
+	}
+}
+
+func (v *validator) location(path string, location *Location) {
+	if location.Region == "" {
+		v.addf(path+".region", "is required")
+	}
+	if len(location.Zones) == 0 {
+		v.addf(path+".zones", "at least one zone is required")
+	}
+	for i, zone := range location.Zones {
+		if zone == "" {
+			v.addf(fmt.Sprintf("%s.zones[%d]", path, i), "must not be empty")
+		}
+	}
+	if location.HostsPerRack < 0 {
+		v.addf(path+".hosts_per_rack", "must not be negative")
+	}
+}
+
+func (v *validator) network(path string, n *Network) {
+	if n.RackOversubscription != 0 && n.RackOversubscription < 1 {
+		v.addf(path+".rack_oversubscription", "must be at least 1, got %g", n.RackOversubscription)
+	}
+	if n.SpineOversubscription != 0 && n.SpineOversubscription < 1 {
+		v.addf(path+".spine_oversubscription", "must be at least 1, got %g", n.SpineOversubscription)
+	}
+	if n.StorageBandwidth < 0 {
+		v.addf(path+".storage_bandwidth", "must not be negative")
+	}
+	latencies := map[string]Duration{"rack": n.Latency.Rack, "zone": n.Latency.Zone, "region": n.Latency.Region, "inter_region": n.Latency.InterRegion}
+	for _, name := range []string{"rack", "zone", "region", "inter_region"} {
+		if latencies[name] < 0 {
+			v.addf(path+".latency."+name, "must not be negative")
+		}
+	}
+}
+
+func (v *validator) migration(path string, m *Migration) {
+	if m.Mode != "" {
+		if _, err := orchestrator.ParseMigrationMode(m.Mode); err != nil {
+			v.addf(path+".mode", "%v", err)
+		}
+	}
+	if m.DirtyFraction < 0 || m.DirtyFraction > 1 {
+		v.addf(path+".dirty_fraction", "must be in [0, 1], got %g", m.DirtyFraction)
+	}
+	if m.Bandwidth < 0 {
+		v.addf(path+".bandwidth", "must not be negative")
+	}
+}
+
+func (v *validator) powerCap(path string, p *PowerCap, classes []HostClass) {
+	if p.Mode != "" {
+		if _, err := broker.ParsePowerCapMode(p.Mode); err != nil {
+			v.addf(path+".mode", "%v", err)
+		}
+	}
+	if p.RackBudget < 0 {
+		v.addf(path+".rack_budget", "must not be negative")
+	}
+	if p.ClusterBudget < 0 {
+		v.addf(path+".cluster_budget", "must not be negative")
+	}
+	if p.RackBudget == 0 && p.ClusterBudget == 0 {
+		v.addf(path, "needs a rack_budget or a cluster_budget")
+	}
+	if p.RackBudget > 0 {
+		located := false
+		for _, class := range classes {
+			located = located || class.Location != nil
+		}
+		if !located {
+			v.addf(path+".rack_budget", "needs host classes with a location to place hosts in racks")
+		}
+	}
+}
+
+func (v *validator) migrationLimits(path string, l *MigrationLimits) {
+	for name, load := range map[string]float64{"trigger_load": l.TriggerLoad, "target_load": l.TargetLoad} {
+		if load < 0 || load > 1 {
+			v.addf(path+"."+name, "must be a load in (0, 1], got %g", load)
+		}
+	}
+	// Unset levels fall back to the default threshold, so a lone
+	// trigger_load must not undercut it either.
+	trigger, target := l.TriggerLoad, l.TargetLoad
+	if trigger == 0 {
+		trigger = orchestrator.DefaultLoadThreshold
+	}
+	if target == 0 {
+		target = orchestrator.DefaultLoadThreshold
+	}
+	if target > trigger {
+		if l.TargetLoad == 0 {
+			v.addf(path+".trigger_load", "must not be below the default target_load (%g) when target_load is unset, got %g", target, trigger)
+		} else {
+			v.addf(path+".target_load", "must not exceed trigger_load (%g), got %g", trigger, target)
+		}
+	}
+	if l.Cooldown < 0 {
+		v.addf(path+".cooldown", "must not be negative")
+	}
+	if l.Budget < 0 {
+		v.addf(path+".budget", "must not be negative")
+	}
+	if l.Budget > 0 && l.Window <= 0 {
+		v.addf(path+".window", "must be positive when a budget is set")
+	}
+}
+
+func (v *validator) percent(path string, value float64) {
+	if value <= 0 || value > 100 {
+		v.addf(path, "must be a percentage in (0, 100], got %g", value)
+	}
+}
+
+func (v *validator) intRange(path string, r IntRange, min int) {
+	if r.Min < min {
+		v.addf(path+".min", "must be at least %d, got %d", min, r.Min)
+	}
+	if r.Max < r.Min {
+		v.addf(path+".max", "must not be less than min (%d), got %d", r.Min, r.Max)
+	}
+}
+
+func (v *validator) floatRange(path string, r FloatRange) {
+	if r.Min <= 0 {
+		v.addf(path+".min", "must be positive, got %g", r.Min)
+	}
+	if r.Max < r.Min {
+		v.addf(path+".max", "must not be less than min (%g), got %g", r.Min, r.Max)
+	}
+}
+
+func (v *validator) durationRange(path string, r DurationRange) {
+	if r.Min < 0 {
+		v.addf(path+".min", "must not be negative, got %s", time.Duration(r.Min))
+	}
+	if r.Max < r.Min {
+		v.addf(path+".max", "must not be less than min (%s), got %s", time.Duration(r.Min), time.Duration(r.Max))
+	}
+}
@@ -0,0 +1,363 @@
+package scenario
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// baseScenario is a small valid scenario that each case breaks in one place.
+const baseScenario = `{
+  "name": "base",
+  "duration": "1h",
+  "workload_change_interval": "10m",
+  "strategies": ["BinPacking"],
+  "qos": {"cpu": 80, "memory": 85, "gpu": 95, "io": 75},
+  "gpu_models": [{
+    "name": "generic",
+    "cuda_cores": {"min": 3584, "max": 11776},
+    "tensor_cores": {"min": 224, "max": 736},
+    "vram": {"min": 8192, "max": 57344},
+    "memory_bandwidth": {"min": 900, "max": 3300},
+    "tflops": {"min": 13.4, "max": 32.1},
+    "power_consumption": {"min": 250, "max": 500}
+  }],
+  "host_classes": [{
+    "name": "standard",
+    "count": 4,
+    "cpu_cores": {"min": 32, "max": 64},
+    "memory": {"min": 65536, "max": 131072},
+    "gpu_model": "generic",
+    "gpus_per_host": 2
+  }],
+  "workloads": [{
+    "name": "default",
+    "count": 8,
+    "cpu_request": {"min": 1000, "max": 4000},
+    "memory_request": {"min": 2048, "max": 8192},
+    "priority": {"min": 1, "max": 4},
+    "gpu_model": "generic",
+    "runtime": {"min": "10m", "max": "30m"}
+  }]
+}`
+
+// set replaces the value at a dotted path such as "host_classes.0.count",
+// creating intermediate objects as needed.
+func set(t *testing.T, doc map[string]any, path string, value any) {
+	t.Helper()
+	keys := strings.Split(path, ".")
+	var node any = doc
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch n := node.(type) {
+		case map[string]any:
+			if last {
+				n[key] = value
+				return
+			}
+			if _, ok := n[key]; !ok {
+				n[key] = map[string]any{}
+			}
+			node = n[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index >= len(n) {
+				t.Fatalf("bad index %q in path %q", key, path)
+			}
+			if last {
+				n[index] = value
+				return
+			}
+			node = n[index]
+		default:
+			t.Fatalf("path %q runs through a scalar at %q", path, key)
+		}
+	}
+}
+
+// writeScenario writes data to a scenario file in a fresh directory.
+func writeScenario(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBaseScenario(t *testing.T) {
+	if _, err := Load(writeScenario(t, []byte(baseScenario))); err != nil {
+		t.Fatalf("base scenario should load: %v", err)
+	}
+}
+
+func TestValidateRejectsBadScenarios(t *testing.T) {
+	tests := []struct {
+		name  string
+		edits map[string]any
+		want  string
+	}{
+		{
+			name:  "zero duration",
+			edits: map[string]any{"duration": "0s"},
+			want:  "duration: must be positive",
+		},
+		{
+			name:  "unknown strategy",
+			edits: map[string]any{"strategies": []any{"Fastest"}},
+			want:  `strategies[0]: unknown strategy "Fastest"`,
+		},
+		{
+			name:  "duplicate strategy",
+			edits: map[string]any{"strategies": []any{"BinPacking", "BinPacking"}},
+			want:  `strategies[1]: duplicate strategy "BinPacking"`,
+		},
+		{
+			name:  "unknown rebalance policy",
+			edits: map[string]any{"rebalance_policies": []any{"shuffle"}},
+			want:  `rebalance_policies[0]: unknown rebalance policy "shuffle"`,
+		},
+		{
+			name:  "qos threshold above 100",
+			edits: map[string]any{"qos.cpu": 120},
+			want:  "qos.cpu: must be a percentage in (0, 100], got 120",
+		},
+		{
+			name:  "inverted range",
+			edits: map[string]any{"gpu_models.0.vram.max": 1024},
+			want:  "gpu_models[0].vram.max: must not be less than min (8192), got 1024",
+		},
+		{
+			name:  "idle power above TDP",
+			edits: map[string]any{"gpu_models.0.idle_power": map[string]any{"min": 100, "max": 300}},
+			want:  "gpu_models[0].idle_power.max: must not exceed power_consumption.min (250), got 300",
+		},
+		{
+			name:  "no host classes",
+			edits: map[string]any{"host_classes": []any{}},
+			want:  "host_classes: at least one host class is required",
+		},
+		{
+			name:  "unknown host GPU model",
+			edits: map[string]any{"host_classes.0.gpu_model": "h100x"},
+			want:  `host_classes[0].gpu_model: unknown GPU model "h100x"`,
+		},
+		{
+			name:  "gpus spec combined with gpu_model",
+			edits: map[string]any{"host_classes.0.gpus": "2x generic"},
+			want:  "host_classes[0].gpus: cannot be combined with gpu_model or gpus_per_host",
+		},
+		{
+			name:  "mig without configuration",
+			edits: map[string]any{"host_classes.0.mig": true},
+			want:  `host_classes[0].mig: GPU model "generic" has no MIG configuration`,
+		},
+		{
+			name:  "label shadows built-in attribute",
+			edits: map[string]any{"host_classes.0.gpu_labels": map[string]any{"vram": "lots"}},
+			want:  `host_classes[0].gpu_labels: label "vram" shadows a built-in GPU attribute`,
+		},
+		{
+			name:  "bandwidth contention under time-slicing",
+			edits: map[string]any{"host_classes.0.sharing": map[string]any{"mode": "time-slicing", "max_tenants": 4, "tenant_overhead": 0.05, "bandwidth_contention": 0.1}},
+			want:  "host_classes[0].sharing.bandwidth_contention: only applies to mps, where tenants run concurrently",
+		},
+		{
+			name:  "zero workload count",
+			edits: map[string]any{"workloads.0.count": 0},
+			want:  "workloads[0].count: must be positive",
+		},
+		{
+			name:  "gpu fraction above one",
+			edits: map[string]any{"workloads.0.gpu_fraction": 1.5},
+			want:  "workloads[0].gpu_fraction: must be in (0, 1], got 1.5",
+		},
+		{
+			name:  "malformed constraint",
+			edits: map[string]any{"workloads.0.gpu_constraint": "vram >="},
+			want:  "workloads[0].gpu_constraint: ",
+		},
+		{
+			name:  "unknown constraint attribute",
+			edits: map[string]any{"workloads.0.gpu_constraint": "colour == 'green'"},
+			want:  `workloads[0].gpu_constraint: unknown GPU attribute "colour"`,
+		},
+		{
+			name:  "dataset without network",
+			edits: map[string]any{"workloads.0.dataset_size": map[string]any{"min": 10, "max": 20}},
+			want:  "workloads[0].dataset_size: only applies when the scenario has a network",
+		},
+		{
+			name:  "gang timeout without gang",
+			edits: map[string]any{"workloads.0.gang_timeout": "5m"},
+			want:  "workloads[0].gang_timeout: only applies when gang_size is above 1",
+		},
+		{
+			name:  "placement above host level without location",
+			edits: map[string]any{"workloads.0.placement": []any{map[string]any{"level": "rack", "policy": "spread"}}},
+			want:  `workloads[0].placement[0].level: host class "standard" has no location`,
+		},
+		{
+			name:  "target load above trigger load",
+			edits: map[string]any{"migration_limits": map[string]any{"trigger_load": 0.7, "target_load": 0.9}},
+			want:  "migration_limits.target_load: must not exceed trigger_load (0.7), got 0.9",
+		},
+		{
+			name:  "target load above the default trigger load",
+			edits: map[string]any{"migration_limits": map[string]any{"target_load": 0.9}},
+			want:  "migration_limits.target_load: must not exceed trigger_load (0.8), got 0.9",
+		},
+		{
+			name:  "trigger load below the default target load",
+			edits: map[string]any{"migration_limits": map[string]any{"trigger_load": 0.7}},
+			want:  "migration_limits.trigger_load: must not be below the default target_load (0.8) when target_load is unset, got 0.7",
+		},
+		{
+			name:  "budget without window",
+			edits: map[string]any{"migration_limits": map[string]any{"budget": 3}},
+			want:  "migration_limits.window: must be positive when a budget is set",
+		},
+		{
+			name:  "drain load out of range",
+			edits: map[string]any{"consolidation": map[string]any{"interval": "10m", "drain_load": 1, "boot_time": "2m"}},
+			want:  "consolidation.drain_load: must be a load in (0, 1), got 1",
+		},
+		{
+			name:  "power cap without budget",
+			edits: map[string]any{"power_cap": map[string]any{"mode": "throttle"}},
+			want:  "power_cap: needs a rack_budget or a cluster_budget",
+		},
+		{
+			name:  "unknown power cap mode",
+			edits: map[string]any{"power_cap": map[string]any{"mode": "panic", "cluster_budget": 5000}},
+			want:  `power_cap.mode: unknown power cap mode "panic"`,
+		},
+		{
+			name:  "rack budget without location",
+			edits: map[string]any{"power_cap": map[string]any{"rack_budget": 5000}},
+			want:  "power_cap.rack_budget: needs host classes with a location to place hosts in racks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc map[string]any
+			if err := json.Unmarshal([]byte(baseScenario), &doc); err != nil {
+				t.Fatal(err)
+			}
+			for path, value := range tt.edits {
+				set(t, doc, path, value)
+			}
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = Load(writeScenario(t, data))
+			if err == nil {
+				t.Fatalf("Load succeeded, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), "invalid scenario") {
+				t.Errorf("error %q does not come from validation", err)
+			}
+			if !strings.Contains(err.Error(), "  "+tt.want) {
+				t.Errorf("error does not contain %q:\n%v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(baseScenario), &doc); err != nil {
+		t.Fatal(err)
+	}
+	set(t, doc, "duration", "0s")
+	set(t, doc, "workloads.0.count", 0)
+	data, _ := json.Marshal(doc)
+
+	_, err := Load(writeScenario(t, data))
+	if err == nil {
+		t.Fatal("Load succeeded, want errors")
+	}
+	for _, want := range []string{"duration: must be positive", "workloads[0].count: must be positive"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsMalformedFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "unknown field",
+			data: strings.Replace(baseScenario, `"name": "base"`, `"nmae": "base"`, 1),
+			want: `unknown field "nmae"`,
+		},
+		{
+			name: "wrong type",
+			data: strings.Replace(baseScenario, `"count": 4`, `"count": "four"`, 1),
+			want: `line 18, column 20: field "host_classes.0.count" must be int, got string`,
+		},
+		{
+			name: "syntax error",
+			data: strings.Replace(baseScenario, `"strategies": ["BinPacking"],`, `"strategies": ["BinPacking"]`, 1),
+			want: "line 6, column 4: invalid character",
+		},
+		{
+			name: "missing catalog",
+			data: strings.Replace(baseScenario, `"name": "base"`, `"name": "base", "gpu_catalog": "missing.json"`, 1),
+			want: "gpu_catalog: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScenario(t, []byte(tt.data))
+			_, err := Load(path)
+			if err == nil {
+				t.Fatalf("Load succeeded, want error containing %q", tt.want)
+			}
+			if !strings.HasPrefix(err.Error(), path+": ") {
+				t.Errorf("error %q is not prefixed with the file name", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error does not contain %q:\n%v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadShippedScenarios(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "scenarios", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scenarios found")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			s, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Name == "" {
+				t.Errorf("scenario has no name")
+			}
+		})
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("default scenario is invalid: %v", err)
+	}
+}
@@ -1,22 +1,19 @@
 package scheduler
 
 import (
-	"fmt"
 	"gpu-cloudsim/models"
 	"sort"
 )
 
 type BinPackingStrategy struct{}
 
+// Allocate binds each container to the first GPU with enough free capacity
+// for its GPU request, reserving that capacity on the GPU.
 func (b *BinPackingStrategy) Allocate(containers []models.Container, gpus []models.GPU) error {
 	for i := range containers {
 		for j := range gpus {
-			if gpus[j].CUDACores >= containers[i].GPURequest.CUDACores &&
-				gpus[j].VRAM >= containers[i].GPURequest.VRAM &&
-				gpus[j].MemoryBandwidth >= containers[i].GPURequest.MemoryBandwidth &&
-				gpus[j].PowerConsumption >= containers[i].GPURequest.PowerConsumption {
-
-				containers[i].GPURequest = &gpus[j]
+			if gpus[j].Allocate(containers[i].GPURequest) == nil {
+				containers[i].GPUBindings = []models.GPUBinding{{GPUID: gpus[j].ID}}
 				break
 			}
 		}
@@ -24,7 +21,8 @@ func (b *BinPackingStrategy) Allocate(containers []models.Container, gpus []mode
 	return nil
 }
 
-func (b *BinPackingStrategy) Schedule(containers []*models.Container, hosts []*models.Host) error {
+func (b *BinPackingStrategy) Schedule(containers []*models.Container, hosts []*models.Host) []*models.Container {
+	var unplaced []*models.Container
 	// Sort containers by resource requirements (descending order)
 	sort.Slice(containers, func(i, j int) bool {
 		return containers[i].CPURequest > containers[j].CPURequest
@@ -32,16 +30,19 @@ func (b *BinPackingStrategy) Schedule(containers []*models.Container, hosts []*m
 
 	for _, container := range containers {
 		allocated := false
-		for _, host := range hosts {
-			if canAllocate(container, host) {
-				host.AddContainer(container)
+		candidates := models.RankHosts(container, hosts)
+		for _, host := range candidates {
+			if host.Allocate(container) == nil {
 				allocated = true
 				break
 			}
 		}
+		if !allocated && container.GPURequest.MultiHost {
+			allocated = models.AllocateAcross(container, candidates) == nil
+		}
 		if !allocated {
-			return fmt.Errorf("unable to allocate resources for container %s", container.ID)
+			unplaced = append(unplaced, container)
 		}
 	}
-	return nil
+	return unplaced
 }
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"fmt"
+	"gpu-cloudsim/models"
+	"sort"
+	"strings"
+)
+
+// PowerCapped is the reason given for containers that would fit but that
+// the power budgets turn away.
+const PowerCapped = "held back by the power cap"
+
+// Explain describes why the container cannot be placed on the hosts right
+// now. feasible reports whether it could be placed once enough capacity is
+// freed; when it is false no host could ever run the container, because no
+// host is large enough or no GPU matches its constraints. Required placement
+// rules only ever block a container until other members of its group finish.
+func Explain(c *models.Container, hosts []*models.Host) (reason string, feasible bool) {
+	maxCPU, maxMemory := 0, 0
+	for _, host := range hosts {
+		maxCPU = max(maxCPU, host.CPUCores*1000)
+		maxMemory = max(maxMemory, host.Memory)
+	}
+	if !c.GPURequest.MultiHost {
+		if c.CPURequest > maxCPU {
+			return fmt.Sprintf("requests %d millicores, the largest host has %d", c.CPURequest, maxCPU), false
+		}
+		if c.MemoryRequest > maxMemory {
+			return fmt.Sprintf("requests %d MB of memory, the largest host has %d", c.MemoryRequest, maxMemory), false
+		}
+	}
+
+	if count := c.GPURequest.Count; count > 0 {
+		mismatches := map[string]int{}
+		matching, mostOnHost := 0, 0
+		for _, host := range hosts {
+			onHost := 0
+			for _, gpu := range host.GPUs {
+				if why := staticMismatch(c.GPURequest, gpu); why != "" {
+					mismatches[why]++
+					continue
+				}
+				onHost++
+			}
+			matching += onHost
+			mostOnHost = max(mostOnHost, onHost)
+		}
+		switch {
+		case matching == 0 && len(mismatches) == 0:
+			return "the cluster has no GPUs", false
+		case matching == 0:
+			return "no GPU matches: " + describeMismatches(mismatches), false
+		case c.GPURequest.MultiHost && matching < count:
+			return fmt.Sprintf("requests %d GPUs, only %d in the cluster match", count, matching), false
+		case !c.GPURequest.MultiHost && mostOnHost < count:
+			return fmt.Sprintf("requests %d GPUs on one host, at most %d match on any host", count, mostOnHost), false
+		}
+	}
+
+	if rule := models.PlacementBlocked(c, hosts); rule != "" {
+		return fmt.Sprintf("no host allows %s within group %s", rule, c.Group), true
+	}
+	for _, host := range hosts {
+		if host.Power == models.PoweredOn && host.CouldFit(c) && !host.WithinPowerBudgets(c) {
+			return PowerCapped, true
+		}
+	}
+	return "waiting for free capacity", true
+}
+
+// staticMismatch returns why the GPU could never serve the request, however
+// much of it is free, or "" if it could.
+func staticMismatch(request models.GPURequirement, gpu *models.GPU) string {
+	if why := request.Explain(gpu); why != "" {
+		return why
+	}
+	if request.Profile != "" || gpu.Sharing != nil {
+		return ""
+	}
+	if request.CUDACores > gpu.CUDACores || request.VRAM > gpu.VRAM || request.MemoryBandwidth > gpu.MemoryBandwidth {
+		return "per-GPU request exceeds device capacity"
+	}
+	return ""
+}
+
+// describeMismatches lists the reasons GPUs were rejected, most common first.
+func describeMismatches(mismatches map[string]int) string {
+	reasons := make([]string, 0, len(mismatches))
+	for reason := range mismatches {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if mismatches[reasons[i]] != mismatches[reasons[j]] {
+			return mismatches[reasons[i]] > mismatches[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%s fails on %d GPUs", reason, mismatches[reason])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,14 +1,14 @@
 package scheduler
 
 import (
-	"fmt"
 	"gpu-cloudsim/models"
 	"sort"
 )
 
 type PrioritySchedulingStrategy struct{}
 
-func (p *PrioritySchedulingStrategy) Schedule(containers []*models.Container, hosts []*models.Host) error {
+func (p *PrioritySchedulingStrategy) Schedule(containers []*models.Container, hosts []*models.Host) []*models.Container {
+	var unplaced []*models.Container
 	// Sort containers by priority (higher priority first)
 	sort.Slice(containers, func(i, j int) bool {
 		return containers[i].Priority > containers[j].Priority
@@ -16,39 +16,19 @@ func (p *PrioritySchedulingStrategy) Schedule(containers []*models.Container, ho
 
 	for _, container := range containers {
 		allocated := false
-		for _, host := range hosts {
-			if canAllocate(container, host) {
-				host.AddContainer(container)
+		candidates := models.RankHosts(container, hosts)
+		for _, host := range candidates {
+			if host.Allocate(container) == nil {
 				allocated = true
 				break
 			}
 		}
-		if !allocated {
-			return fmt.Errorf("unable to allocate resources for container %s", container.ID)
+		if !allocated && container.GPURequest.MultiHost {
+			allocated = models.AllocateAcross(container, candidates) == nil
 		}
-	}
-	return nil
-}
-
-func canAllocate(container *models.Container, host *models.Host) bool {
-	// Check CPU cores (convert millicores to cores)
-	if float64(host.CPUCores) < float64(container.CPURequest)/1000 {
-		return false
-	}
-
-	// Check Memory
-	if host.Memory < container.MemoryRequest {
-		return false
-	}
-
-	// Check if any GPU on the host meets the requirements
-	for _, gpu := range host.GPUs {
-		if gpu.CUDACores >= container.GPURequest.CUDACores &&
-			gpu.VRAM >= container.GPURequest.VRAM &&
-			gpu.MemoryBandwidth >= container.GPURequest.MemoryBandwidth {
-			return true // Found a suitable GPU
+		if !allocated {
+			unplaced = append(unplaced, container)
 		}
 	}
-
-	return false
+	return unplaced
 }
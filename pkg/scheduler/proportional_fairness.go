@@ -24,20 +24,14 @@ func (p *ProportionalFairnessStrategy) Allocate(containers []models.Container, g
 		allocated := false
 		requiredCores := int(float64(containers[i].GPURequest.CUDACores) / float64(totalNeed) * float64(totalGPUCores))
 
+		// Reserve the proportional share of cores rather than the raw request
+		share := containers[i].GPURequest
+		share.CUDACores = requiredCores
+
 		for j := range gpus {
-			if gpus[j].CUDACores >= requiredCores &&
-				gpus[j].VRAM >= containers[i].GPURequest.VRAM &&
-				gpus[j].MemoryBandwidth >= containers[i].GPURequest.MemoryBandwidth &&
-				gpus[j].PowerConsumption >= containers[i].GPURequest.PowerConsumption {
-
-				// Allocate GPU to container
-				containers[i].GPURequest = &gpus[j]
-
-				// Update remaining GPU resources
-				gpus[j].CUDACores -= requiredCores
-				gpus[j].VRAM -= containers[i].GPURequest.VRAM
-				gpus[j].MemoryBandwidth -= containers[i].GPURequest.MemoryBandwidth
-				gpus[j].PowerConsumption -= containers[i].GPURequest.PowerConsumption
+			if gpus[j].Allocate(share) == nil {
+				// Bind GPU to container
+				containers[i].GPUBindings = []models.GPUBinding{{GPUID: gpus[j].ID}}
 
 				allocated = true
 				break
@@ -1,30 +1,37 @@
 package scheduler
 
 import (
-	"fmt"
 	"gpu-cloudsim/models"
+	"slices"
 )
 
 type RoundRobinStrategy struct {
 	currentHostIndex int
 }
 
-func (r *RoundRobinStrategy) Schedule(containers []*models.Container, hosts []*models.Host) error {
+func (r *RoundRobinStrategy) Schedule(containers []*models.Container, hosts []*models.Host) []*models.Container {
+	var unplaced []*models.Container
 	for _, container := range containers {
 		allocated := false
-		for i := 0; i < len(hosts); i++ {
-			hostIndex := (r.currentHostIndex + i) % len(hosts)
-			host := hosts[hostIndex]
-			if canAllocate(container, host) {
-				host.AddContainer(container)
+		// Take hosts from the current round-robin position on
+		rotated := append(append([]*models.Host{}, hosts[r.currentHostIndex:]...), hosts[:r.currentHostIndex]...)
+		candidates := models.RankHosts(container, rotated)
+		for _, host := range candidates {
+			if host.Allocate(container) == nil {
 				allocated = true
-				r.currentHostIndex = (hostIndex + 1) % len(hosts)
+				r.currentHostIndex = (slices.Index(hosts, host) + 1) % len(hosts)
 				break
 			}
 		}
+		if !allocated && container.GPURequest.MultiHost && len(hosts) > 0 {
+			if models.AllocateAcross(container, candidates) == nil {
+				allocated = true
+				r.currentHostIndex = (r.currentHostIndex + 1) % len(hosts)
+			}
+		}
 		if !allocated {
-			return fmt.Errorf("unable to allocate resources for container %s", container.ID)
+			unplaced = append(unplaced, container)
 		}
 	}
-	return nil
+	return unplaced
 }
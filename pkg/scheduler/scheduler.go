@@ -1,9 +1,39 @@
 package scheduler
 
 import (
+	"fmt"
 	"gpu-cloudsim/models"
+	"sort"
 )
 
 type Scheduler interface {
-	Schedule(containers []*models.Container, hosts []*models.Host) error
+	// Schedule places as many of the containers as fit on the hosts and
+	// returns the ones that could not be placed.
+	Schedule(containers []*models.Container, hosts []*models.Host) []*models.Container
+}
+
+// factories maps the strategy names accepted in scenario files to constructors.
+var factories = map[string]func() Scheduler{
+	"Priority":   func() Scheduler { return &PrioritySchedulingStrategy{} },
+	"BinPacking": func() Scheduler { return &BinPackingStrategy{} },
+	"RoundRobin": func() Scheduler { return &RoundRobinStrategy{} },
+}
+
+// New returns a fresh scheduler for the named strategy.
+func New(name string) (Scheduler, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (known strategies: %v)", name, Names())
+	}
+	return factory(), nil
+}
+
+// Names returns the known strategy names in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }